@@ -2,13 +2,16 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
 	"monkey/token"
+	"strconv"
 	"strings"
 )
 
 type Node interface {
   TokenLiteral() string
   String() string
+  Pos() token.Position // position of the node's token, e.g. the operator of an infix expression
 }
 
 type Statement interface {
@@ -23,6 +26,7 @@ type Expression interface {
 
 type Program struct { // The root node of the AST the parser produces.
   Statements []Statement // slice of statement nodes, (Every valid program written in Monkey is a series of statements)
+  Comments []token.Comment // every comment in the source in order, each one is also on the Leading trivia of the token after it
 }
 
 func (p *Program) TokenLiteral() string {
@@ -33,6 +37,14 @@ func (p *Program) TokenLiteral() string {
   return ""
 }
 
+func (p *Program) Pos() token.Position {
+  if len(p.Statements) > 0 {
+    return p.Statements[0].Pos()
+  }
+
+  return token.Position{}
+}
+
 func (p *Program) String() string {
   var out bytes.Buffer
 
@@ -45,7 +57,9 @@ func (p *Program) String() string {
 
 type LetStatement struct {
   Name *Identifier
+  Pattern Expression // an *ArrayPattern or *HashPattern when the let destructures its value, Name is nil then
   Value Expression
+  Exported bool // written export let, the binding is visible to the files importing this one
   Token token.Token // token.LET
 }
 
@@ -55,11 +69,22 @@ func (ls *LetStatement) TokenLiteral() string {
   return ls.Token.Literal
 }
 
+func (ls *LetStatement) Pos() token.Position {
+  return ls.Token.Pos
+}
+
 func (ls *LetStatement) String() string {
   var out bytes.Buffer
 
+  if ls.Exported {
+    out.WriteString("export ")
+  }
   out.WriteString(ls.TokenLiteral() + " ")
-  out.WriteString(ls.Name.String())
+  if ls.Pattern != nil {
+    out.WriteString(ls.Pattern.String())
+  } else {
+    out.WriteString(ls.Name.String())
+  }
   out.WriteString(" = ")
 
   if ls.Value != nil {
@@ -82,6 +107,10 @@ func (rs *ReturnStatement) TokenLiteral() string {
   return rs.Token.Literal
 }
 
+func (rs *ReturnStatement) Pos() token.Position {
+  return rs.Token.Pos
+}
+
 func (rs *ReturnStatement) String() string {
   var out bytes.Buffer
   out.WriteString(rs.TokenLiteral() + " ")
@@ -105,6 +134,10 @@ func (es *ExpressionStatement) TokenLiteral() string {
   return es.Token.Literal
 }
 
+func (es *ExpressionStatement) Pos() token.Position {
+  return es.Token.Pos
+}
+
 func (es *ExpressionStatement) String() string {
   if es.Expression != nil {
     return es.Expression.String()
@@ -124,6 +157,10 @@ func (i *Identifier) TokenLiteral() string {
   return i.Token.Literal
 }
 
+func (i *Identifier) Pos() token.Position {
+  return i.Token.Pos
+}
+
 func (i *Identifier) String() string {
   return i.Value
 }
@@ -131,12 +168,24 @@ func (i *Identifier) String() string {
 type IntegerLiteral struct {
   Token token.Token
   Value int64
+  Big *big.Int // the value of a literal too large for an int64, Value is 0 then
 }
 
 func (il *IntegerLiteral) expressionNode() {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal } 
+func (il *IntegerLiteral) Pos() token.Position { return il.Token.Pos }
 func (il *IntegerLiteral) String() string { return il.Token.Literal }
 
+type FloatLiteral struct {
+  Token token.Token
+  Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Position { return fl.Token.Pos }
+func (fl *FloatLiteral) String() string { return fl.Token.Literal }
+
 type PrefixExpression struct {
   Token token.Token // the prefix token, e.g. !
   Operator string
@@ -145,6 +194,7 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode() {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Position { return pe.Token.Pos }
 func (pe *PrefixExpression) String() string {
   var out bytes.Buffer
 
@@ -168,6 +218,10 @@ func (ie *InfixExpression) expressionNode() {}
 func (ie *InfixExpression) TokenLiteral() string {
   return ie.Token.Literal
 }
+
+func (ie *InfixExpression) Pos() token.Position {
+  return ie.Token.Pos
+}
 func (ie *InfixExpression) String() string {
   var out bytes.Buffer
 
@@ -187,8 +241,19 @@ type Boolean struct {
 
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Pos }
 func (b *Boolean) String() string       { return b.Token.Literal }
 
+// Null is the null value in code built by unquote, there is no literal for it in source.
+type Null struct {
+  Token token.Token
+}
+
+func (n *Null) expressionNode()      {}
+func (n *Null) TokenLiteral() string { return n.Token.Literal }
+func (n *Null) Pos() token.Position  { return n.Token.Pos }
+func (n *Null) String() string       { return "null" }
+
 type IfExpression struct {
   Token token.Token // the 'if' token
   Condition Expression
@@ -198,6 +263,7 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode() {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Position { return ie.Token.Pos }
 func (ie *IfExpression) String() string {
   var out bytes.Buffer
 
@@ -221,6 +287,7 @@ type BlockStatement struct {
 
 func (bs *BlockStatement) statementNode() {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position { return bs.Token.Pos }
 func (bs *BlockStatement) String() string {
   var out bytes.Buffer
 
@@ -234,18 +301,19 @@ func (bs *BlockStatement) String() string {
 type FunctionLiteral struct {
   Token token.Token // the 'fn' token
   Parameters []*Identifier
+  Defaults []Expression // Defaults[i] is the default value of Parameters[i], nil when no parameter has one
+  Rest *Identifier // the ...rest parameter collecting the remaining arguments, nil if there is none
   Body *BlockStatement
+  Name string // the name a let statement bound the literal to, for tracebacks only, String leaves it out
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Pos() token.Position { return fl.Token.Pos }
 func (fl *FunctionLiteral) String() string {
   var out bytes.Buffer
 
-  params := []string{}
-  for _, p := range fl.Parameters {
-    params = append(params, p.String())
-  }
+  params := ParametersString(fl.Parameters, fl.Defaults, fl.Rest)
 
   out.WriteString(fl.TokenLiteral())
   out.WriteString("(")
@@ -256,6 +324,24 @@ func (fl *FunctionLiteral) String() string {
   return out.String()
 }
 
+// ParametersString lists a function's parameters the way they are written in source.
+func ParametersString(parameters []*Identifier, defaults []Expression, rest *Identifier) []string {
+  params := []string{}
+  for i, p := range parameters {
+    if i < len(defaults) && defaults[i] != nil {
+      params = append(params, p.String() + " = " + defaults[i].String())
+    } else {
+      params = append(params, p.String())
+    }
+  }
+
+  if rest != nil {
+    params = append(params, "..." + rest.String())
+  }
+
+  return params
+}
+
 type CallExpression struct {
   Token token.Token // the '(' token
   Function Expression // Identifier of FunctionLiteral
@@ -264,6 +350,7 @@ type CallExpression struct {
 
 func (ce *CallExpression) expressionNode() {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Position { return ce.Token.Pos }
 func (ce *CallExpression) String() string {
   var out bytes.Buffer
 
@@ -287,8 +374,20 @@ type StringLiteral struct {
 
 func (sl *StringLiteral) expressionNode() {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position { return sl.Token.Pos }
 func (sl *StringLiteral) String() string { return sl.Token.Literal }
 
+// ImportExpression loads another file as a module, import "lib/math.mk" evaluates to its exports.
+type ImportExpression struct {
+  Token token.Token // token.IMPORT
+  Path *StringLiteral
+}
+
+func (ie *ImportExpression) expressionNode() {}
+func (ie *ImportExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *ImportExpression) Pos() token.Position { return ie.Token.Pos }
+func (ie *ImportExpression) String() string { return "import " + strconv.Quote(ie.Path.Value) }
+
 type ArrayLiteral struct {
   Token     token.Token
   Elements  []Expression
@@ -296,6 +395,7 @@ type ArrayLiteral struct {
 
 func (al *ArrayLiteral) expressionNode() {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Position { return al.Token.Pos }
 func (al *ArrayLiteral) String() string {
   var out bytes.Buffer
   
@@ -319,6 +419,7 @@ type IndexExpression struct {
 
 func (ie *IndexExpression) expressionNode() {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position { return ie.Token.Pos }
 func (ie *IndexExpression) String() string {
   var out bytes.Buffer
 
@@ -331,19 +432,26 @@ func (ie *IndexExpression) String() string {
   return out.String()
 }
 
+// HashPair is one key: value of a hash literal.
+type HashPair struct {
+  Key Expression
+  Value Expression
+}
+
 type HashLiteral struct {
   Token token.Token // the '{' token
-  Pairs map[Expression]Expression
+  Pairs []HashPair // in source order
 }
 
 func (hl *HashLiteral) expressionNode() {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position { return hl.Token.Pos }
 func (hl *HashLiteral) String() string {
   var out bytes.Buffer
 
   pairs := []string{}
-  for key, value := range hl.Pairs {
-    pairs = append(pairs, key.String() + ": " + value.String())
+  for _, pair := range hl.Pairs {
+    pairs = append(pairs, pair.Key.String() + ": " + pair.Value.String())
   }
 
   out.WriteString("{")
@@ -351,4 +459,208 @@ func (hl *HashLiteral) String() string {
   out.WriteString("}")
 
   return out.String()
-}
\ No newline at end of file
+}
+
+// ArrayPattern is the left side of a destructuring let such as let [a, [b, c], ...rest] = xs;
+type ArrayPattern struct {
+  Token token.Token // the '[' token
+  Elements []Expression // each one an *Identifier, *ArrayPattern or *HashPattern
+  Rest *Identifier // collects the elements after the ones matched by Elements, nil if there is none
+}
+
+func (ap *ArrayPattern) expressionNode() {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) Pos() token.Position { return ap.Token.Pos }
+func (ap *ArrayPattern) String() string {
+  var out bytes.Buffer
+
+  elements := []string{}
+  for _, el := range ap.Elements {
+    elements = append(elements, el.String())
+  }
+  if ap.Rest != nil {
+    elements = append(elements, "..." + ap.Rest.String())
+  }
+
+  out.WriteString("[")
+  out.WriteString(strings.Join(elements, ", "))
+  out.WriteString("]")
+
+  return out.String()
+}
+
+// HashPattern is the left side of a destructuring let such as let {"name": n, "tags": [first]} = h;
+type HashPattern struct {
+  Token token.Token // the '{' token
+  Keys []Expression
+  Values []Expression // Values[i] receives the value at Keys[i], an *Identifier, *ArrayPattern or *HashPattern
+}
+
+func (hp *HashPattern) expressionNode() {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) Pos() token.Position { return hp.Token.Pos }
+func (hp *HashPattern) String() string {
+  var out bytes.Buffer
+
+  pairs := []string{}
+  for i, key := range hp.Keys {
+    pairs = append(pairs, key.String() + ": " + hp.Values[i].String())
+  }
+
+  out.WriteString("{")
+  out.WriteString(strings.Join(pairs, ", "))
+  out.WriteString("}")
+
+  return out.String()
+}
+
+type MacroLiteral struct {
+  Token token.Token // the 'macro' token
+  Parameters []*Identifier
+  Body *BlockStatement
+}
+
+func (ml *MacroLiteral) expressionNode() {}
+func (ml *MacroLiteral) TokenLiteral() string { return ml.Token.Literal }
+func (ml *MacroLiteral) Pos() token.Position { return ml.Token.Pos }
+func (ml *MacroLiteral) String() string {
+  var out bytes.Buffer
+
+  params := []string{}
+  for _, p := range ml.Parameters {
+    params = append(params, p.String())
+  }
+
+  out.WriteString(ml.TokenLiteral())
+  out.WriteString("(")
+  out.WriteString(strings.Join(params, ", "))
+  out.WriteString(") ")
+  out.WriteString(ml.Body.String())
+
+  return out.String()
+}
+
+type WhileStatement struct {
+  Token token.Token // the 'while' token
+  Condition Expression
+  Body *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) Pos() token.Position { return ws.Token.Pos }
+func (ws *WhileStatement) String() string {
+  var out bytes.Buffer
+
+  out.WriteString("while")
+  out.WriteString(ws.Condition.String())
+  out.WriteString(" ")
+  out.WriteString(ws.Body.String())
+
+  return out.String()
+}
+
+// ForStatement is a C-style loop, for (init; condition; post) { ... }. Any of the three clauses may be left out.
+type ForStatement struct {
+  Token token.Token // the 'for' token
+  Init Statement // a let or expression statement, nil if left out
+  Condition Expression // nil loops until a break
+  Post Statement // a let or expression statement, nil if left out
+  Body *BlockStatement
+}
+
+func (fs *ForStatement) statementNode() {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) Pos() token.Position { return fs.Token.Pos }
+func (fs *ForStatement) String() string {
+  var out bytes.Buffer
+
+  out.WriteString("for (")
+  if fs.Init != nil {
+    out.WriteString(strings.TrimSuffix(fs.Init.String(), ";"))
+  }
+  out.WriteString("; ")
+  if fs.Condition != nil {
+    out.WriteString(fs.Condition.String())
+  }
+  out.WriteString("; ")
+  if fs.Post != nil {
+    out.WriteString(strings.TrimSuffix(fs.Post.String(), ";"))
+  }
+  out.WriteString(") ")
+  out.WriteString(fs.Body.String())
+
+  return out.String()
+}
+
+// ForInStatement loops over the elements of an array, the characters of a string or the keys of a hash,
+// for (x in xs) { ... }. With two names, for (i, x in xs), the first one gets the index, or for a hash the
+// key, and the second one the element or value.
+type ForInStatement struct {
+  Token token.Token // the 'for' token
+  Key *Identifier // nil unless two names are given
+  Value *Identifier
+  Iterable Expression
+  Body *BlockStatement
+}
+
+func (fi *ForInStatement) statementNode() {}
+func (fi *ForInStatement) TokenLiteral() string { return fi.Token.Literal }
+func (fi *ForInStatement) Pos() token.Position { return fi.Token.Pos }
+func (fi *ForInStatement) String() string {
+  var out bytes.Buffer
+
+  out.WriteString("for (")
+  if fi.Key != nil {
+    out.WriteString(fi.Key.String() + ", ")
+  }
+  out.WriteString(fi.Value.String())
+  out.WriteString(" in ")
+  out.WriteString(fi.Iterable.String())
+  out.WriteString(") ")
+  out.WriteString(fi.Body.String())
+
+  return out.String()
+}
+
+type BreakStatement struct {
+  Token token.Token // the 'break' token
+}
+
+func (bs *BreakStatement) statementNode() {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) Pos() token.Position { return bs.Token.Pos }
+func (bs *BreakStatement) String() string { return bs.Token.Literal + ";" }
+
+type ContinueStatement struct {
+  Token token.Token // the 'continue' token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) Pos() token.Position { return cs.Token.Pos }
+func (cs *ContinueStatement) String() string { return cs.Token.Literal + ";" }
+
+// AssignExpression updates a variable, x = 1, an array element, xs[0] = 1, or a hash entry, h["k"] = 1.
+// The compound operators, as in x += 1, combine the current value with the new one first.
+type AssignExpression struct {
+  Token token.Token // the assignment operator
+  Target Expression // an *Identifier or an *IndexExpression
+  Operator string // "=", "+=", "-=", "*=" or "/="
+  Value Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) Pos() token.Position { return ae.Token.Pos }
+func (ae *AssignExpression) String() string {
+  var out bytes.Buffer
+
+  out.WriteString("(")
+  out.WriteString(ae.Target.String())
+  out.WriteString(" " + ae.Operator + " ")
+  out.WriteString(ae.Value.String())
+  out.WriteString(")")
+
+  return out.String()
+}
@@ -0,0 +1,149 @@
+package ast
+
+type ModifierFunc func(Node) Node
+
+// Modify walks the tree depth first, replacing each child with whatever the modifier returns for it,
+// and finally calls the modifier on node itself. Every node type is handled, leaves are simply passed
+// to the modifier. A modifier that returns a node of the wrong kind for its slot, say an expression
+// where a statement is expected, leaves that slot nil.
+func Modify(node Node, modifier ModifierFunc) Node {
+  switch node := node.(type) {
+  case *Program:
+    for i, statement := range node.Statements {
+      node.Statements[i], _ = Modify(statement, modifier).(Statement)
+    }
+
+  case *ExpressionStatement:
+    node.Expression = modifyExpression(node.Expression, modifier)
+
+  case *InfixExpression:
+    node.Left = modifyExpression(node.Left, modifier)
+    node.Right = modifyExpression(node.Right, modifier)
+
+  case *PrefixExpression:
+    node.Right = modifyExpression(node.Right, modifier)
+
+  case *IndexExpression:
+    node.Left = modifyExpression(node.Left, modifier)
+    node.Index = modifyExpression(node.Index, modifier)
+
+  case *AssignExpression:
+    node.Target = modifyExpression(node.Target, modifier)
+    node.Value = modifyExpression(node.Value, modifier)
+
+  case *IfExpression:
+    node.Condition = modifyExpression(node.Condition, modifier)
+    node.Consequence, _ = Modify(node.Consequence, modifier).(*BlockStatement)
+    if node.Alternative != nil {
+      node.Alternative, _ = Modify(node.Alternative, modifier).(*BlockStatement)
+    }
+
+  case *BlockStatement:
+    for i := range node.Statements {
+      node.Statements[i], _ = Modify(node.Statements[i], modifier).(Statement)
+    }
+
+  case *ReturnStatement:
+    node.ReturnValue = modifyExpression(node.ReturnValue, modifier)
+
+  case *LetStatement:
+    if node.Pattern != nil {
+      node.Pattern = modifyExpression(node.Pattern, modifier)
+    } else {
+      node.Name, _ = Modify(node.Name, modifier).(*Identifier)
+    }
+    node.Value = modifyExpression(node.Value, modifier)
+
+  case *ArrayPattern:
+    for i := range node.Elements {
+      node.Elements[i] = modifyExpression(node.Elements[i], modifier)
+    }
+    if node.Rest != nil {
+      node.Rest, _ = Modify(node.Rest, modifier).(*Identifier)
+    }
+
+  case *HashPattern:
+    for i := range node.Keys {
+      node.Keys[i] = modifyExpression(node.Keys[i], modifier)
+      node.Values[i] = modifyExpression(node.Values[i], modifier)
+    }
+
+  case *FunctionLiteral:
+    node.Parameters = modifyIdentifiers(node.Parameters, modifier)
+    for i := range node.Defaults {
+      node.Defaults[i] = modifyExpression(node.Defaults[i], modifier)
+    }
+    if node.Rest != nil {
+      node.Rest, _ = Modify(node.Rest, modifier).(*Identifier)
+    }
+    node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+
+  case *MacroLiteral:
+    node.Parameters = modifyIdentifiers(node.Parameters, modifier)
+    node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+
+  case *CallExpression:
+    node.Function = modifyExpression(node.Function, modifier)
+    for i := range node.Arguments {
+      node.Arguments[i] = modifyExpression(node.Arguments[i], modifier)
+    }
+
+  case *ArrayLiteral:
+    for i := range node.Elements {
+      node.Elements[i] = modifyExpression(node.Elements[i], modifier)
+    }
+
+  case *HashLiteral:
+    for i := range node.Pairs {
+      node.Pairs[i].Key = modifyExpression(node.Pairs[i].Key, modifier)
+      node.Pairs[i].Value = modifyExpression(node.Pairs[i].Value, modifier)
+    }
+
+  case *WhileStatement:
+    node.Condition = modifyExpression(node.Condition, modifier)
+    node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+
+  case *ForStatement:
+    if node.Init != nil {
+      node.Init, _ = Modify(node.Init, modifier).(Statement)
+    }
+    node.Condition = modifyExpression(node.Condition, modifier)
+    if node.Post != nil {
+      node.Post, _ = Modify(node.Post, modifier).(Statement)
+    }
+    node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+
+  case *ForInStatement:
+    if node.Key != nil {
+      node.Key, _ = Modify(node.Key, modifier).(*Identifier)
+    }
+    node.Value, _ = Modify(node.Value, modifier).(*Identifier)
+    node.Iterable = modifyExpression(node.Iterable, modifier)
+    node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+
+  case *ImportExpression:
+    node.Path, _ = Modify(node.Path, modifier).(*StringLiteral)
+
+  case *Identifier, *IntegerLiteral, *FloatLiteral, *Boolean, *StringLiteral, *BreakStatement, *ContinueStatement:
+    // leaves, only the modifier below applies
+  }
+
+  return modifier(node)
+}
+
+func modifyExpression(exp Expression, modifier ModifierFunc) Expression {
+  if exp == nil {
+    return nil
+  }
+
+  modified, _ := Modify(exp, modifier).(Expression)
+  return modified
+}
+
+func modifyIdentifiers(identifiers []*Identifier, modifier ModifierFunc) []*Identifier {
+  for i := range identifiers {
+    identifiers[i], _ = Modify(identifiers[i], modifier).(*Identifier)
+  }
+
+  return identifiers
+}
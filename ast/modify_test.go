@@ -0,0 +1,213 @@
+package ast
+
+import (
+  "reflect"
+  "testing"
+)
+
+func TestModify(t *testing.T) {
+  one := func() Expression { return &IntegerLiteral{Value: 1} }
+  two := func() Expression { return &IntegerLiteral{Value: 2} }
+
+  turnOneIntoTwo := func(node Node) Node {
+    integer, ok := node.(*IntegerLiteral)
+    if !ok {
+      return node
+    }
+
+    if integer.Value != 1 {
+      return node
+    }
+
+    integer.Value = 2
+    return integer
+  }
+
+  tests := []struct {
+    input    Node
+    expected Node
+  }{
+    {
+      one(),
+      two(),
+    },
+    {
+      &Program{
+        Statements: []Statement{
+          &ExpressionStatement{Expression: one()},
+        },
+      },
+      &Program{
+        Statements: []Statement{
+          &ExpressionStatement{Expression: two()},
+        },
+      },
+    },
+    {
+      &InfixExpression{Left: one(), Operator: "+", Right: two()},
+      &InfixExpression{Left: two(), Operator: "+", Right: two()},
+    },
+    {
+      &InfixExpression{Left: two(), Operator: "+", Right: one()},
+      &InfixExpression{Left: two(), Operator: "+", Right: two()},
+    },
+    {
+      &PrefixExpression{Operator: "-", Right: one()},
+      &PrefixExpression{Operator: "-", Right: two()},
+    },
+    {
+      &IndexExpression{Left: one(), Index: one()},
+      &IndexExpression{Left: two(), Index: two()},
+    },
+    {
+      &IfExpression{
+        Condition: one(),
+        Consequence: &BlockStatement{
+          Statements: []Statement{
+            &ExpressionStatement{Expression: one()},
+          },
+        },
+        Alternative: &BlockStatement{
+          Statements: []Statement{
+            &ExpressionStatement{Expression: one()},
+          },
+        },
+      },
+      &IfExpression{
+        Condition: two(),
+        Consequence: &BlockStatement{
+          Statements: []Statement{
+            &ExpressionStatement{Expression: two()},
+          },
+        },
+        Alternative: &BlockStatement{
+          Statements: []Statement{
+            &ExpressionStatement{Expression: two()},
+          },
+        },
+      },
+    },
+    {
+      &ReturnStatement{ReturnValue: one()},
+      &ReturnStatement{ReturnValue: two()},
+    },
+    {
+      &LetStatement{Value: one()},
+      &LetStatement{Value: two()},
+    },
+    {
+      &FunctionLiteral{
+        Parameters: []*Identifier{},
+        Body: &BlockStatement{
+          Statements: []Statement{
+            &ExpressionStatement{Expression: one()},
+          },
+        },
+      },
+      &FunctionLiteral{
+        Parameters: []*Identifier{},
+        Body: &BlockStatement{
+          Statements: []Statement{
+            &ExpressionStatement{Expression: two()},
+          },
+        },
+      },
+    },
+    {
+      &AssignExpression{Target: &Identifier{Value: "x"}, Operator: "+=", Value: one()},
+      &AssignExpression{Target: &Identifier{Value: "x"}, Operator: "+=", Value: two()},
+    },
+    {
+      &ArrayLiteral{Elements: []Expression{one(), one()}},
+      &ArrayLiteral{Elements: []Expression{two(), two()}},
+    },
+    {
+      &CallExpression{Function: &Identifier{Value: "add"}, Arguments: []Expression{one(), two()}},
+      &CallExpression{Function: &Identifier{Value: "add"}, Arguments: []Expression{two(), two()}},
+    },
+    {
+      &MacroLiteral{
+        Parameters: []*Identifier{},
+        Body: &BlockStatement{
+          Statements: []Statement{
+            &ReturnStatement{ReturnValue: one()},
+          },
+        },
+      },
+      &MacroLiteral{
+        Parameters: []*Identifier{},
+        Body: &BlockStatement{
+          Statements: []Statement{
+            &ReturnStatement{ReturnValue: two()},
+          },
+        },
+      },
+    },
+  }
+
+  for _, tt := range tests {
+    modified := Modify(tt.input, turnOneIntoTwo)
+
+    equal := reflect.DeepEqual(modified, tt.expected)
+    if !equal {
+      t.Errorf("not equal. got=%#v, want=%#v", modified, tt.expected)
+    }
+  }
+
+  hashLiteral := &HashLiteral{
+    Pairs: []HashPair{
+      {Key: one(), Value: one()},
+      {Key: one(), Value: one()},
+    },
+  }
+
+  Modify(hashLiteral, turnOneIntoTwo)
+
+  for _, pair := range hashLiteral.Pairs {
+    key, _ := pair.Key.(*IntegerLiteral)
+    if key.Value != 2 {
+      t.Errorf("value is not %d, got=%d", 2, key.Value)
+    }
+    val, _ := pair.Value.(*IntegerLiteral)
+    if val.Value != 2 {
+      t.Errorf("value is not %d, got=%d", 2, val.Value)
+    }
+  }
+}
+
+func TestModifyIdentifiers(t *testing.T) {
+  program := &Program{
+    Statements: []Statement{
+      &LetStatement{
+        Name: &Identifier{Value: "x"},
+        Value: &FunctionLiteral{
+          Parameters: []*Identifier{{Value: "x"}},
+          Body: &BlockStatement{
+            Statements: []Statement{
+              &ExpressionStatement{Expression: &Identifier{Value: "x"}},
+            },
+          },
+        },
+      },
+    },
+  }
+
+  renameX := func(node Node) Node {
+    if ident, ok := node.(*Identifier); ok && ident.Value == "x" {
+      return &Identifier{Value: "y"}
+    }
+    return node
+  }
+
+  Modify(program, renameX)
+
+  let := program.Statements[0].(*LetStatement)
+  fn := let.Value.(*FunctionLiteral)
+  body := fn.Body.Statements[0].(*ExpressionStatement).Expression.(*Identifier)
+
+  for _, name := range []string{let.Name.Value, fn.Parameters[0].Value, body.Value} {
+    if name != "y" {
+      t.Errorf("identifier not renamed. got=%q", name)
+    }
+  }
+}
@@ -0,0 +1,164 @@
+package ast
+
+// A Visitor's Visit method is called by Walk for every node it meets. If the returned visitor w is not nil,
+// Walk visits each child of node with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+  Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree depth first in source order, starting with node itself.
+func Walk(v Visitor, node Node) {
+  if v = v.Visit(node); v == nil {
+    return
+  }
+
+  switch n := node.(type) {
+  case *Program:
+    for _, statement := range n.Statements {
+      Walk(v, statement)
+    }
+
+  case *LetStatement:
+    if n.Pattern != nil {
+      Walk(v, n.Pattern)
+    } else {
+      Walk(v, n.Name)
+    }
+    walkExpression(v, n.Value)
+
+  case *ArrayPattern:
+    for _, el := range n.Elements {
+      Walk(v, el)
+    }
+    if n.Rest != nil {
+      Walk(v, n.Rest)
+    }
+
+  case *HashPattern:
+    for i, key := range n.Keys {
+      Walk(v, key)
+      Walk(v, n.Values[i])
+    }
+
+  case *ReturnStatement:
+    walkExpression(v, n.ReturnValue)
+
+  case *ExpressionStatement:
+    walkExpression(v, n.Expression)
+
+  case *BlockStatement:
+    for _, statement := range n.Statements {
+      Walk(v, statement)
+    }
+
+  case *PrefixExpression:
+    walkExpression(v, n.Right)
+
+  case *InfixExpression:
+    walkExpression(v, n.Left)
+    walkExpression(v, n.Right)
+
+  case *IfExpression:
+    walkExpression(v, n.Condition)
+    Walk(v, n.Consequence)
+    if n.Alternative != nil {
+      Walk(v, n.Alternative)
+    }
+
+  case *FunctionLiteral:
+    for i, param := range n.Parameters {
+      Walk(v, param)
+      if i < len(n.Defaults) {
+        walkExpression(v, n.Defaults[i])
+      }
+    }
+    if n.Rest != nil {
+      Walk(v, n.Rest)
+    }
+    Walk(v, n.Body)
+
+  case *MacroLiteral:
+    for _, param := range n.Parameters {
+      Walk(v, param)
+    }
+    Walk(v, n.Body)
+
+  case *CallExpression:
+    walkExpression(v, n.Function)
+    for _, arg := range n.Arguments {
+      walkExpression(v, arg)
+    }
+
+  case *ArrayLiteral:
+    for _, el := range n.Elements {
+      walkExpression(v, el)
+    }
+
+  case *IndexExpression:
+    walkExpression(v, n.Left)
+    walkExpression(v, n.Index)
+
+  case *AssignExpression:
+    walkExpression(v, n.Target)
+    walkExpression(v, n.Value)
+
+  case *HashLiteral:
+    for _, pair := range n.Pairs {
+      walkExpression(v, pair.Key)
+      walkExpression(v, pair.Value)
+    }
+
+  case *WhileStatement:
+    walkExpression(v, n.Condition)
+    Walk(v, n.Body)
+
+  case *ForStatement:
+    if n.Init != nil {
+      Walk(v, n.Init)
+    }
+    walkExpression(v, n.Condition)
+    if n.Post != nil {
+      Walk(v, n.Post)
+    }
+    Walk(v, n.Body)
+
+  case *ForInStatement:
+    if n.Key != nil {
+      Walk(v, n.Key)
+    }
+    Walk(v, n.Value)
+    walkExpression(v, n.Iterable)
+    Walk(v, n.Body)
+
+  case *ImportExpression:
+    Walk(v, n.Path)
+
+  case *Identifier, *IntegerLiteral, *FloatLiteral, *Boolean, *StringLiteral, *BreakStatement, *ContinueStatement:
+    // leaves, nothing to walk
+  }
+
+  v.Visit(nil)
+}
+
+// walkExpression skips the nil expressions a partially built tree can hold.
+func walkExpression(v Visitor, exp Expression) {
+  if exp != nil {
+    Walk(v, exp)
+  }
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+  if f(node) {
+    return f
+  }
+
+  return nil
+}
+
+// Inspect calls f for node and then, as long as f returns true, for each of node's children.
+// After the children of a node it calls f(nil).
+func Inspect(node Node, f func(Node) bool) {
+  Walk(inspector(f), node)
+}
@@ -0,0 +1,148 @@
+package ast
+
+import (
+  "reflect"
+  "testing"
+)
+
+func TestInspect(t *testing.T) {
+  // let add = fn(a, b) { if (a > b) { return [a, b[0]]; } else { add(-a, {"k": true}) } };
+  program := &Program{
+    Statements: []Statement{
+      &LetStatement{
+        Name: &Identifier{Value: "add"},
+        Value: &FunctionLiteral{
+          Parameters: []*Identifier{{Value: "a"}, {Value: "b"}},
+          Body: &BlockStatement{
+            Statements: []Statement{
+              &ExpressionStatement{
+                Expression: &IfExpression{
+                  Condition: &InfixExpression{Left: &Identifier{Value: "a"}, Operator: ">", Right: &Identifier{Value: "b"}},
+                  Consequence: &BlockStatement{
+                    Statements: []Statement{
+                      &ReturnStatement{
+                        ReturnValue: &ArrayLiteral{
+                          Elements: []Expression{
+                            &Identifier{Value: "a"},
+                            &IndexExpression{Left: &Identifier{Value: "b"}, Index: &IntegerLiteral{Value: 0}},
+                          },
+                        },
+                      },
+                    },
+                  },
+                  Alternative: &BlockStatement{
+                    Statements: []Statement{
+                      &ExpressionStatement{
+                        Expression: &CallExpression{
+                          Function: &Identifier{Value: "add"},
+                          Arguments: []Expression{
+                            &PrefixExpression{Operator: "-", Right: &Identifier{Value: "a"}},
+                            &HashLiteral{
+                              Pairs: []HashPair{
+                                {Key: &StringLiteral{Value: "k"}, Value: &Boolean{Value: true}},
+                              },
+                            },
+                          },
+                        },
+                      },
+                    },
+                  },
+                },
+              },
+            },
+          },
+        },
+      },
+    },
+  }
+
+  var visited []string
+  Inspect(program, func(node Node) bool {
+    if node != nil {
+      visited = append(visited, reflect.TypeOf(node).Elem().Name())
+    }
+    return true
+  })
+
+  expected := []string{
+    "Program", "LetStatement", "Identifier", "FunctionLiteral", "Identifier", "Identifier",
+    "BlockStatement", "ExpressionStatement", "IfExpression", "InfixExpression", "Identifier", "Identifier",
+    "BlockStatement", "ReturnStatement", "ArrayLiteral", "Identifier", "IndexExpression", "Identifier", "IntegerLiteral",
+    "BlockStatement", "ExpressionStatement", "CallExpression", "Identifier", "PrefixExpression", "Identifier",
+    "HashLiteral", "StringLiteral", "Boolean",
+  }
+
+  if !reflect.DeepEqual(visited, expected) {
+    t.Errorf("wrong visiting order.\nwant=%v\ngot= %v", expected, visited)
+  }
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+  program := &Program{
+    Statements: []Statement{
+      &ExpressionStatement{
+        Expression: &FunctionLiteral{
+          Parameters: []*Identifier{{Value: "x"}},
+          Body: &BlockStatement{
+            Statements: []Statement{
+              &ExpressionStatement{Expression: &Identifier{Value: "x"}},
+            },
+          },
+        },
+      },
+      &ExpressionStatement{Expression: &Identifier{Value: "y"}},
+    },
+  }
+
+  identifiers := []string{}
+  Inspect(program, func(node Node) bool {
+    if _, ok := node.(*FunctionLiteral); ok {
+      return false
+    }
+    if ident, ok := node.(*Identifier); ok {
+      identifiers = append(identifiers, ident.Value)
+    }
+    return true
+  })
+
+  if !reflect.DeepEqual(identifiers, []string{"y"}) {
+    t.Errorf("expected only the identifier outside the function. got=%v", identifiers)
+  }
+}
+
+type depthCounter struct {
+  depth    int
+  maxDepth *int
+}
+
+func (d depthCounter) Visit(node Node) Visitor {
+  if node == nil {
+    return nil
+  }
+  if d.depth > *d.maxDepth {
+    *d.maxDepth = d.depth
+  }
+  return depthCounter{depth: d.depth + 1, maxDepth: d.maxDepth}
+}
+
+func TestWalk(t *testing.T) {
+  // (1 + (2 * 3))
+  program := &Program{
+    Statements: []Statement{
+      &ExpressionStatement{
+        Expression: &InfixExpression{
+          Left:     &IntegerLiteral{Value: 1},
+          Operator: "+",
+          Right:    &InfixExpression{Left: &IntegerLiteral{Value: 2}, Operator: "*", Right: &IntegerLiteral{Value: 3}},
+        },
+      },
+    },
+  }
+
+  maxDepth := 0
+  Walk(depthCounter{maxDepth: &maxDepth}, program)
+
+  if maxDepth != 4 {
+    t.Errorf("wrong depth. want=%d, got=%d", 4, maxDepth)
+  }
+}
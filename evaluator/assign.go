@@ -0,0 +1,107 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// Assignments are expressions that evaluate to the assigned value, so a = b = 1 sets both. A compound
+// operator such as += applies the matching infix operator to the current value and the new one.
+
+func evalAssignExpression(ae *ast.AssignExpression, env *object.Environment) object.Object {
+	val := Eval(ae.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	switch target := ae.Target.(type) {
+	case *ast.Identifier:
+		return evalIdentifierAssignment(target, ae.Operator, val, env)
+	case *ast.IndexExpression:
+		return evalIndexAssignment(target, ae.Operator, val, env)
+	default:
+		return newError("cannot assign to %s", ae.Target.String())
+	}
+}
+
+func evalIdentifierAssignment(ident *ast.Identifier, operator string, val object.Object, env *object.Environment) object.Object {
+	current, ok := env.Get(ident.Value)
+	if !ok {
+		return newError("cannot assign to undeclared identifier: %s", ident.Value)
+	}
+
+	val = combineAssignment(operator, current, val)
+	if isError(val) {
+		return val
+	}
+
+	env.Assign(ident.Value, val)
+	return val
+}
+
+func evalIndexAssignment(ie *ast.IndexExpression, operator string, val object.Object, env *object.Environment) object.Object {
+	left := Eval(ie.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	index := Eval(ie.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		if index.Type() != object.INTEGER_OBJ {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("index out of range: %s", index.Inspect())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(left.Elements)) {
+			return newError("index out of range: %d", idx.Value)
+		}
+
+		val = combineAssignment(operator, left.Elements[idx.Value], val)
+		if isError(val) {
+			return val
+		}
+
+		left.Elements[idx.Value] = val
+		return val
+
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+
+		if operator != "=" {
+			pair, ok := left.Pairs[key.HashKey()]
+			if !ok {
+				return newError("key not found: %s", index.Inspect())
+			}
+
+			val = combineAssignment(operator, pair.Value, val)
+			if isError(val) {
+				return val
+			}
+		}
+
+		left.Set(key.HashKey(), object.HashPair{ Key: index, Value: val })
+		return val
+
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+}
+
+// combineAssignment returns the value a compound assignment stores, plain = stores val unchanged.
+func combineAssignment(operator string, current, val object.Object) object.Object {
+	if operator == "=" {
+		return val
+	}
+
+	return evalInfixExpression(operator[:len(operator)-1], current, val)
+}
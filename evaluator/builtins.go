@@ -1,113 +1,32 @@
 package evaluator
 
 import (
-	"monkey/object"
+  "monkey/object"
 )
 
-var builtins = map[string]*object.Builtin{
-  "len": &object.Builtin{
-    Fn: func(args ...object.Object) object.Object {
-      if len(args) != 1 {
-        return newError("wrong number of arguments. got=%d, expected=1", len(args))
-      }
-
-      switch arg := args[0].(type) {
-      case *object.String:
-        return &object.Integer{Value: int64(len(arg.Value))}
-      
-      case *object.Array:
-        return &object.Integer{Value: int64(len(arg.Elements))}
-        
-      default:
-        return newError("argument to `len` not supported, got %s", args[0].Type())
-      }
-    },
-  },
-  "first": &object.Builtin{
-    Fn: func(args ...object.Object) object.Object {
-      if len(args) != 1 {
-        return newError("wrong number of arguments. got=%d, expected=1", len(args))
-      }
-
-      if args[0].Type() != object.ARRAY_OBJ {
-        return newError("argument to `first` must be ARRAY, got %s", args[0].Type())
-      }
-
-      arr := args[0].(*object.Array)
-      if len(arr.Elements) > 0 {
-        return arr.Elements[0]
-      }
-
-      return NULL
-    },
-  },
-  "last": &object.Builtin{
-    Fn: func(args ...object.Object) object.Object {
-      if len(args) != 1 {
-        return newError("wrong number of arguments. got=%d, expected=1", len(args))
-      }
-
-      if args[0].Type() != object.ARRAY_OBJ {
-        return newError("argument to `last` must be ARRAY, got %s", args[0].Type())
-      }
-
-      arr := args[0].(*object.Array)
-      length := len(arr.Elements)
-      if length > 0 {
-        return arr.Elements[length-1]
-      }
-
-      return NULL
-    },
-  },
-  "rest": &object.Builtin{
-    Fn: func(args ...object.Object) object.Object {
-      if len(args) != 1 {
-        return newError("wrong number of arguments. got=%d, expected=1", len(args))
-      }
-
-      if args[0].Type() != object.ARRAY_OBJ {
-        return newError("argument to `rest` must be ARRAY, got %s", args[0].Type())
-      }
-
-      arr := args[0].(*object.Array)
-      length := len(arr.Elements)
-      if length > 0 {
-        newElements := make([]object.Object, length-1, length-1)
-        copy(newElements, arr.Elements[1:length])
-        return &object.Array{Elements: newElements}
-      }
-
-      return NULL
-    },
-  },
-  "push": &object.Builtin{
-    Fn: func(args ...object.Object) object.Object {
-      if len(args) != 2 {
-        return newError("wrong number of arguments. got=%d, expected=2", len(args))
-      }
-
-      if args[0].Type() != object.ARRAY_OBJ {
-        return newError("argument to `push` must be ARRAY, got %s", args[0].Type())
-      }
-
-      arr := args[0].(*object.Array)
-      length := len(arr.Elements)
-
-      newElements := make([]object.Object, length+1, length+1)
-      copy(newElements, arr.Elements)
-      newElements[length] = args[1]
-
-      return &object.Array{Elements: newElements}
-    },
-  },
-  "puts": &object.Builtin{
-    Fn: func(args ...object.Object) object.Object {
-      for _, arg := range args {
-        println(arg.Inspect())
-      }
-
-      return NULL
-    },
-  },
+var builtins = map[string]object.Object{
+  "len": object.GetBuiltinByName("len"),
+  "first": object.GetBuiltinByName("first"),
+  "last": object.GetBuiltinByName("last"),
+  "rest": object.GetBuiltinByName("rest"),
+  "push": object.GetBuiltinByName("push"),
+  "puts": object.GetBuiltinByName("puts"),
+  "float": object.GetBuiltinByName("float"),
+  "int": object.GetBuiltinByName("int"),
+  "strings": object.Strings,
+  "map": object.GetBuiltinByName("map"),
+  "filter": object.GetBuiltinByName("filter"),
+  "reduce": object.GetBuiltinByName("reduce"),
+  "each": object.GetBuiltinByName("each"),
+  "find": object.GetBuiltinByName("find"),
+  "any": object.GetBuiltinByName("any"),
+  "all": object.GetBuiltinByName("all"),
+  "sort": object.GetBuiltinByName("sort"),
+  "keys": object.GetBuiltinByName("keys"),
+  "values": object.GetBuiltinByName("values"),
+  "entries": object.GetBuiltinByName("entries"),
+  "has": object.GetBuiltinByName("has"),
+  "delete": object.GetBuiltinByName("delete"),
+  "merge": object.GetBuiltinByName("merge"),
+  "size": object.GetBuiltinByName("size"),
 }
@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// A destructuring let binds every name in its pattern. Elements or keys the value doesn't have bind
+// null, and so does everything below a pattern whose value is null, which makes nested patterns
+// forgiving about partial data. Any other mismatch between pattern and value is an error.
+
+func destructure(pattern ast.Expression, val object.Object, env *object.Environment) object.Object {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		env.Set(pattern.Value, val)
+		return nil
+	case *ast.ArrayPattern:
+		return destructureArray(pattern, val, env)
+	case *ast.HashPattern:
+		return destructureHash(pattern, val, env)
+	default:
+		return newError("cannot bind to %s", pattern.String())
+	}
+}
+
+func destructureArray(pattern *ast.ArrayPattern, val object.Object, env *object.Environment) object.Object {
+	var elements []object.Object
+	switch val := val.(type) {
+	case *object.Array:
+		elements = val.Elements
+	case *object.Null:
+	default:
+		return newError("cannot destructure %s with an array pattern", val.Type())
+	}
+
+	for i, element := range pattern.Elements {
+		var elementVal object.Object = NULL
+		if i < len(elements) {
+			elementVal = elements[i]
+		}
+		if err := destructure(element, elementVal, env); err != nil {
+			return err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := []object.Object{}
+		if len(elements) > len(pattern.Elements) {
+			rest = append(rest, elements[len(pattern.Elements):]...)
+		}
+		env.Set(pattern.Rest.Value, &object.Array{ Elements: rest })
+	}
+
+	return nil
+}
+
+func destructureHash(pattern *ast.HashPattern, val object.Object, env *object.Environment) object.Object {
+	hash, isHash := val.(*object.Hash)
+	if !isHash && val != NULL {
+		return newError("cannot destructure %s with a hash pattern", val.Type())
+	}
+
+	for i, keyNode := range pattern.Keys {
+		key := Eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		var value object.Object = NULL
+		if isHash {
+			if pair, ok := hash.Pairs[hashKey.HashKey()]; ok {
+				value = pair.Value
+			}
+		}
+		if err := destructure(pattern.Values[i], value, env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
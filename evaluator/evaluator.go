@@ -1,18 +1,65 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"math/big"
 	"monkey/ast"
 	"monkey/object"
 )
 
 var (
-	TRUE = &object.Boolean{ Value: true }
-	FALSE = &object.Boolean{ Value: false }
-	NULL = &object.Null{}
+	TRUE = object.TRUE
+	FALSE = object.FALSE
+	NULL = object.NULL
+
+	BREAK = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
+// Eval evaluates the node and stamps any error raised directly by it with the node's position,
+// errors coming up from child nodes keep the position they already have.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return step(node, env, eval)
+}
+
+// step runs evalFn on node as one step of the evaluation's budget, stamping any error it raises
+// directly with node's position.
+func step(node ast.Node, env *object.Environment, evalFn func(ast.Node, *object.Environment) object.Object) object.Object {
+	if budget := env.Budget(); budget != nil {
+		if err := budget.Step(); err != nil {
+			err.Pos = node.Pos()
+			return err
+		}
+	}
+
+	result := evalFn(node, env)
+
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+	}
+
+	return result
+}
+
+// Limits bounds an evaluation started with EvalContext, zero values mean the defaults of object.NewBudget.
+type Limits struct {
+	MaxSteps     int // how many nodes may be evaluated, 0 for no limit
+	MaxCallDepth int // how deeply function calls may nest, tail calls included, 0 for object.DefaultMaxCallDepth
+}
+
+// EvalContext evaluates node like Eval, but stops with an error once ctx is done or the evaluation
+// exceeds limits. The budget applies to env and everything evaluated in it until EvalContext returns.
+func EvalContext(ctx context.Context, node ast.Node, env *object.Environment, limits Limits) object.Object {
+	previous := env.Budget()
+	env.SetBudget(object.NewBudget(ctx, limits.MaxSteps, limits.MaxCallDepth))
+	defer env.SetBudget(previous)
+
+	return Eval(node, env)
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	// Statements
 	case *ast.Program:
@@ -22,7 +69,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return Eval(node.Expression, env)
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return evalBlockStatement(node, env, false)
 
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
@@ -36,8 +83,29 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
+		if node.Pattern != nil {
+			if err := destructure(node.Pattern, val, env); err != nil {
+				return err
+			}
+			break
+		}
 		env.Set(node.Name.Value, val)
 
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+
+	case *ast.ForInStatement:
+		return evalForInStatement(node, env)
+
+	case *ast.BreakStatement:
+		return BREAK
+
+	case *ast.ContinueStatement:
+		return CONTINUE
+
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
@@ -49,6 +117,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalIndexExpression(left, index)
 
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 
@@ -64,14 +135,26 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.Array{ Elements: elements }
 
 	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return object.IntegerFromBig(node.Big)
+		}
 		return &object.Integer{ Value: node.Value }
 
+	case *ast.FloatLiteral:
+		return &object.Float{ Value: node.Value }
+
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 
+	case *ast.Null:
+		return NULL
+
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
 
+	case *ast.ImportExpression:
+		return evalImportExpression(node, env)
+
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
@@ -80,6 +163,10 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalPrefixExpression(node.Operator, right)
 
 	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
+
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -97,9 +184,20 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{ Parameters: params, Env: env, Body: body }
+		return &object.Function{ Parameters: params, Defaults: node.Defaults, Rest: node.Rest, Env: env, Body: body, Name: node.Name }
+
+	case *ast.MacroLiteral:
+		// DefineMacros takes every macro out of the program before it runs, one still here isn't defined by a top-level let
+		return newError("macro literal is only allowed in a top-level let")
 		
 	case *ast.CallExpression:
+		if node.Function.TokenLiteral() == "quote" {
+			if len(node.Arguments) != 1 {
+				return newError("wrong number of arguments. got=%d, expected=1", len(node.Arguments))
+			}
+			return quote(node.Arguments[0], env)
+		}
+
 		function := Eval(node.Function, env)
 		if isError(function) {
 			return function
@@ -108,7 +206,12 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+
+		result := applyFunction(function, args)
+		if errObj, ok := result.(*object.Error); ok {
+			addCallFrame(errObj, node, function, args)
+		}
+		return result
 	}
 	return nil
 }
@@ -160,15 +263,21 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment,) object.Object {
+// evalBlockStatement evaluates the statements of block in order. When the block is in tail position of a
+// function body, its last statement is too, see evalTail.
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment, tail bool) object.Object {
 	var result object.Object
 
-	for _, statement := range block.Statements {
-		result = Eval(statement, env)
+	for i, statement := range block.Statements {
+		if tail && i == len(block.Statements)-1 {
+			result = evalTail(statement, env)
+		} else {
+			result = Eval(statement, env)
+		}
 
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
 				return result
 			}
 		}
@@ -210,19 +319,27 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.IntegerFromBig(new(big.Int).Neg(toBig(right)))
+		}
+		return &object.Integer{ Value: -right.Value }
+	case *object.BigInteger:
+		return object.IntegerFromBig(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{ Value: -right.Value }
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-
-	value := right.(*object.Integer).Value
-
-	return &object.Integer{ Value: -value }
 }
 
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
@@ -249,23 +366,58 @@ func evalStringInfixExpression(
 	return &object.String{ Value: leftVal + rightVal }
 }
 
+// evalIntegerInfixExpression works on int64 values as long as the result fits and falls back to
+// math/big when it doesn't, or when an operand already is a BigInteger.
 func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
-	leftVal := left.(*object.Integer).Value
-	rightVal := right.(*object.Integer).Value
+	leftInt, leftOk := left.(*object.Integer)
+	rightInt, rightOk := right.(*object.Integer)
+	if !leftOk || !rightOk {
+		return evalBigIntegerInfixExpression(operator, left, right)
+	}
+
+	leftVal := leftInt.Value
+	rightVal := rightInt.Value
 
 	switch operator {
 	case "+":
-		return &object.Integer{ Value: leftVal + rightVal }
+		sum := leftVal + rightVal
+		if (sum > leftVal) != (rightVal > 0) {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{ Value: sum }
 	case "-":
-		return &object.Integer{ Value: leftVal - rightVal }
+		difference := leftVal - rightVal
+		if (difference < leftVal) != (rightVal > 0) {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{ Value: difference }
 	case "*":
-		return &object.Integer{ Value: leftVal * rightVal }
+		product := leftVal * rightVal
+		if leftVal != 0 && (product/leftVal != rightVal || (leftVal == -1 && rightVal == math.MinInt64)) {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{ Value: product }
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
 		return &object.Integer{ Value: leftVal / rightVal }
+	case "%":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Integer{ Value: leftVal % rightVal }
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -275,12 +427,112 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	}
 }
 
+func evalBigIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toBig(left)
+	rightVal := toBig(right)
+
+	switch operator {
+	case "+":
+		return object.IntegerFromBig(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return object.IntegerFromBig(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return object.IntegerFromBig(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		// Quo truncates toward zero, the same as int64 division
+		return object.IntegerFromBig(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		// Rem takes the sign of the dividend, the same as int64 %
+		return object.IntegerFromBig(new(big.Int).Rem(leftVal, rightVal))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func toBig(obj object.Object) *big.Int {
+	if i, ok := obj.(*object.Integer); ok {
+		return big.NewInt(i.Value)
+	}
+
+	return obj.(*object.BigInteger).Value
+}
+
+// evalFloatInfixExpression handles two floats as well as a float and an integer, the integer is
+// converted first. Division by zero follows IEEE 754 and gives an infinity or NaN.
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{ Value: leftVal + rightVal }
+	case "-":
+		return &object.Float{ Value: leftVal - rightVal }
+	case "*":
+		return &object.Float{ Value: leftVal * rightVal }
+	case "/":
+		return &object.Float{ Value: leftVal / rightVal }
+	case "%":
+		return &object.Float{ Value: math.Mod(leftVal, rightVal) }
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInteger:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	default:
+		return obj.(*object.Float).Value
+	}
+}
+
 func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.MODULE_OBJ:
+		return evalModuleIndexExpression(left.(*object.Module), index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
@@ -303,7 +555,11 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		return NULL // a BigInteger is out of range for any array
+	}
+	idx := integer.Value
 	max := int64(len(arrayObject.Elements) - 1)
 
 	if idx < 0 || idx > max {
@@ -313,6 +569,21 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
+// evalLogicalExpression only evaluates the right operand when the left one doesn't decide the result,
+// and returns whichever operand did decide it, so "a" || "b" is "a" and null && x is null.
+func evalLogicalExpression(ie *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(ie.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	if isTruthy(left) == (ie.Operator == "||") {
+		return left
+	}
+
+	return Eval(ie.Right, env)
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 	
@@ -330,16 +601,7 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 }
 
 func isTruthy(obj object.Object) bool {
-	switch obj {
-	case NULL:
-		return false
-	case TRUE:
-		return true
-	case FALSE:
-		return false
-	default:
-		return true
-	}
+	return object.IsTruthy(obj)
 }
 
 func isError(obj object.Object) bool {
@@ -357,24 +619,147 @@ func newError(format string, a ...interface{}) *object.Error {
 func applyFunction(fn object.Object, args []object.Object) object.Object {
   switch fn := fn.(type) {
     case *object.Function:
-      extendedEnv := extendFunctionEnv(fn, args)
-      evaluated := Eval(fn.Body, extendedEnv)
-      return unwrapReturnValue(evaluated)
+      budget := fn.Env.Budget()
+      if budget == nil {
+        // Even without limits, recursion has to stop before it overflows the Go stack
+        budget = object.NewBudget(nil, 0, 0)
+        fn.Env.SetBudget(budget)
+      }
+
+      if err := budget.Enter(); err != nil {
+        return err
+      }
+      entered := 1
+      defer func() {
+        for ; entered > 0; entered-- {
+          budget.Leave()
+        }
+      }()
+
+      // Calls in tail position come back as a tailCall instead of growing the Go stack,
+      // this loop makes them in place of the function that returned them. They still count
+      // against the call depth, so a function that only ever calls itself stops like any other
+      var tailCalls []*tailCall
+      for {
+        extendedEnv, err := extendFunctionEnv(fn, args)
+        if err != nil {
+          if len(tailCalls) > 0 && !err.Pos.IsValid() {
+            err.Pos = tailCalls[len(tailCalls)-1].call.Pos()
+          }
+          addTailCallFrames(err, tailCalls)
+          return err
+        }
+        evaluated := unwrapReturnValue(evalTail(fn.Body, extendedEnv))
+
+        tc, ok := evaluated.(*tailCall)
+        if !ok {
+          if errObj, ok := evaluated.(*object.Error); ok {
+            addTailCallFrames(errObj, tailCalls)
+          }
+          return evaluated
+        }
+
+        next, ok := tc.fn.(*object.Function)
+        if !ok {
+          result := applyFunction(tc.fn, tc.args)
+          if errObj, ok := result.(*object.Error); ok {
+            if !errObj.Pos.IsValid() {
+              errObj.Pos = tc.call.Pos()
+            }
+            addTailCallFrames(errObj, tailCalls)
+          }
+          return result
+        }
+
+        tailCalls = append(tailCalls, tc)
+        if err := budget.Enter(); err != nil {
+          err.Pos = tc.call.Pos()
+          addTailCallFrames(err, tailCalls)
+          return err
+        }
+        entered++
+
+        fn, args = next, tc.args
+      }
     case *object.Builtin:
-      return fn.Fn(args...)
+      var result object.Object
+      if fn.CallingFn != nil {
+        result = fn.CallingFn(callFunction, args...)
+      } else {
+        result = fn.Fn(args...)
+      }
+      if result != nil {
+        return result
+      }
+      return NULL
     default:
       return newError("not a function: %s", fn.Type())
   }
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// callFunction is the object.Caller builtins get to call back into Monkey.
+func callFunction(fn object.Object, args ...object.Object) object.Object {
+	return applyFunction(fn, args)
+}
+
+// ApplyFunction calls fn, a Monkey function or a builtin, with args. It lets code outside the evaluator,
+// like a Go program embedding Monkey, call back into functions defined in Monkey.
+func ApplyFunction(fn object.Object, args []object.Object) object.Object {
+	return applyFunction(fn, args)
+}
+
+// addCallFrame records the call an error is unwinding through. Each call site on the way out adds
+// itself, so by the time the error leaves Eval its Stack holds the whole call path, innermost first.
+func addCallFrame(err *object.Error, call *ast.CallExpression, fn object.Object, args []object.Object) {
+	function, ok := fn.(*object.Function)
+	if !ok {
+		return // errors from builtins are reported at the call site, there is no Monkey frame to show
+	}
+
+	name := function.Name
+	if name == "" {
+		if ident, ok := call.Function.(*ast.Identifier); ok {
+			name = ident.Value
+		} else {
+			name = "<anonymous>"
+		}
+	}
+
+	frame := object.CallFrame{ Function: name, Pos: call.Pos(), Args: args }
+	err.Stack = append(err.Stack, frame)
+}
+
+// extendFunctionEnv binds the arguments of a call to fn. A parameter left out gets its default value,
+// evaluated in the new environment so it can refer to the parameters before it.
+func extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
+	if err := fn.ArityError(len(args)); err != nil {
+		return nil, err
+	}
+
 	env := object.NewClosedEnvironment(fn.Env)
 
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		if paramIdx < len(args) {
+			env.Set(param.Value, args[paramIdx])
+			continue
+		}
+
+		val := Eval(fn.Defaults[paramIdx], env)
+		if errObj, ok := val.(*object.Error); ok {
+			return nil, errObj
+		}
+		env.Set(param.Value, val)
+	}
+
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{ Elements: rest })
 	}
 
-	return env
+	return env, nil
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -386,10 +771,10 @@ func unwrapReturnValue(obj object.Object) object.Object {
 }
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
 		if isError(key) {
 			return key
 		}
@@ -399,14 +784,13 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(pair.Value, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{ Key: key, Value: value }
+		hash.Set(hashKey.HashKey(), object.HashPair{ Key: key, Value: value })
 	}
 
-	return &object.Hash{ Pairs: pairs }
+	return hash
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"context"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
@@ -27,6 +28,10 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{ "3 * 3 * 3 + 10", 37 },
 		{ "3 * (3 * 3) + 10", 37 },
 		{ "(5 + 10 * 2 + 15 / 3) * 2 + -10", 50 },
+		{ "7 % 3", 1 },
+		{ "-7 % 3", -1 },
+		{ "7 % -3", 1 },
+		{ "2 + 10 % 4 * 3", 8 },
 	}
 
 	for _, tt := range tests {
@@ -35,6 +40,53 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"2.5", 2.5},
+		{"-1.5", -1.5},
+		{"1e3", 1000.0},
+		{"0.1 + 0.2 > 0.3", true},
+		{"7 / 2", 3},
+		{"7 / 2.0", 3.5},
+		{"7.0 / 2", 3.5},
+		{"1 + 0.5 * 3", 2.5},
+		{"2.0 * 3", 6.0},
+		{"1.0 == 1", true},
+		{"1 != 1.5", true},
+		{"1 < 1.5", true},
+		{"2.5 > 3", false},
+		{"let xs = [1, 2, 3, 4]; (xs[0] + xs[1] + xs[2] + xs[3]) / float(len(xs))", 2.5},
+		{"1 / 0", "division by zero"},
+		{"1.5 + true", "type mismatch: FLOAT + BOOLEAN"},
+		{`1.5 + "a"`, "type mismatch: FLOAT + STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		intput 		string
@@ -59,6 +111,13 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{ "(1 < 2) == false", false },
 		{ "(1 > 2) == true", false },
 		{ "(1 > 2) == false", true },
+		{ "1 <= 2", true },
+		{ "2 <= 2", true },
+		{ "3 <= 2", false },
+		{ "1 >= 2", false },
+		{ "2 >= 2", true },
+		{ "1.5 >= 1", true },
+		{ "99999999999999999999 >= 99999999999999999999", true },
 	}
 
 	for _, tt := range tests {
@@ -268,6 +327,69 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 	return true
 }
 
+// errorMessage is the expected message of an error, in tests whose other expectations can be strings.
+type errorMessage string
+
+// inspected is the expected Inspect() of a result, for values like hashes that have no Go counterpart.
+type inspected string
+
+// testExpectedObject checks obj against an expectation of one of the types table tests use: a string is
+// the value of a STRING, slices are arrays and nil is null.
+func testExpectedObject(t *testing.T, input string, obj object.Object, expected interface{}) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		testIntegerObject(t, obj, int64(expected))
+	case float64:
+		testFloatObject(t, obj, expected)
+	case bool:
+		testBooleanObject(t, obj, expected)
+	case nil:
+		testNullObject(t, obj)
+	case string:
+		str, ok := obj.(*object.String)
+		if !ok || str.Value != expected {
+			t.Errorf("wrong result for %s. want=%q, got=%s", input, expected, obj.Inspect())
+		}
+	case []string:
+		arr, ok := obj.(*object.Array)
+		if !ok || len(arr.Elements) != len(expected) {
+			t.Errorf("wrong result for %s. want=%q, got=%s", input, expected, obj.Inspect())
+			return
+		}
+		for i, element := range arr.Elements {
+			if str, ok := element.(*object.String); !ok || str.Value != expected[i] {
+				t.Errorf("wrong element %d for %s. want=%q, got=%s", i, input, expected[i], element.Inspect())
+			}
+		}
+	case []int64:
+		arr, ok := obj.(*object.Array)
+		if !ok || len(arr.Elements) != len(expected) {
+			t.Errorf("wrong result for %s. want=%v, got=%s", input, expected, obj.Inspect())
+			return
+		}
+		for i, element := range arr.Elements {
+			testIntegerObject(t, element, expected[i])
+		}
+	case inspected:
+		if obj.Inspect() != string(expected) {
+			t.Errorf("wrong result for %s. want=%s, got=%s", input, expected, obj.Inspect())
+		}
+	case errorMessage:
+		errObj, ok := obj.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error. got=%T (%+v)", obj, obj)
+			return
+		}
+		if errObj.Message != string(expected) {
+			t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+		}
+	default:
+		t.Fatalf("unsupported expectation %T for %s", expected, input)
+	}
+}
+
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
@@ -295,6 +417,24 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+
+		return false
+	}
+
+	return true
+}
+
 func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 	result, ok := obj.(*object.Boolean)
 
@@ -323,6 +463,17 @@ func TestBuiltinFunctions(t *testing.T) {
     {`len("hello world")`, 11},
     {`len(1)`, "argument to `len` not supported, got INTEGER"},
     {`len("one", "two")`, "wrong number of arguments. got=2, expected=1"},
+    {`float(2)`, 2.0},
+    {`float(1.5)`, 1.5},
+    {`float(" 2.5e1 ")`, 25.0},
+    {`float("x")`, `could not parse "x" as FLOAT`},
+    {`float(true)`, "argument to `float` not supported, got BOOLEAN"},
+    {`int(2.9)`, 2},
+    {`int(-2.9)`, -2},
+    {`int("42")`, 42},
+    {`int(7)`, 7},
+    {`int("4.2")`, `could not parse "4.2" as INTEGER`},
+    {`int(float("nan"))`, "cannot convert NaN to INTEGER"},
   }
 
   for _, tt := range tests {
@@ -331,6 +482,8 @@ func TestBuiltinFunctions(t *testing.T) {
     switch expected := tt.expected.(type) {
     case int:
       testIntegerObject(t, evaluated, int64(expected))
+    case float64:
+      testFloatObject(t, evaluated, expected)
     case string:
       errObj, ok := evaluated.(*object.Error)
       if !ok {
@@ -428,6 +581,36 @@ func TestHashLiteral(t *testing.T) {
 
 		testIntegerObject(t, pair.Value, expectedValue)
 	}
+
+	if result.Inspect() != "{one: 1, two: 2, three: 3, 4: 4, true: 5, false: 6}" {
+		t.Errorf("pairs are not in source order. got=%s", result.Inspect())
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// keys and values are evaluated in source order, key before value
+		{`let s = ""; let f = fn(x) { s += x; x }; {f("c"): f("1"), f("a"): f("2"), f("b"): f("3")}; s`, "c1a2b3"},
+		{`{"c": 1, "a": 2, "b": 3}`, "{c: 1, a: 2, b: 3}"},
+		{`{"a": 1, "b": 2, "a": 3}`, "{a: 3, b: 2}"},
+		{`let h = {"a": 1, "b": 2}; h["a"] = 3; h["c"] = 4; h`, "{a: 3, b: 2, c: 4}"},
+		{`let out = []; for (k, v in {3: "x", 1: "y", 2: "z"}) { out = push(out, k) }; out`, "[3, 1, 2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		result := evaluated.Inspect()
+		if str, ok := evaluated.(*object.String); ok {
+			result = str.Value
+		}
+
+		if result != tt.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", tt.input, tt.expected, result)
+		}
+	}
 }
 
 func TestHashIndexExpressions(t *testing.T) {
@@ -455,3 +638,738 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 + true;", "1:3"},
+		{"let x = 1;\nlet y = -true;", "2:9"},
+		{"let f = fn(a) {\n  a + foo\n};\nf(1);", "2:7"},
+		{`len(1)`, "1:4"},
+		{"let f = fn() { len(1) };\nf();", "1:19"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Pos.String() != tt.expected {
+			t.Errorf("wrong error position for %q. expected=%q, got=%q", tt.input, tt.expected, errObj.Pos.String())
+		}
+	}
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `let inner = fn(a, b) {
+  a + b
+};
+let outer = fn(x) {
+  let helper = fn(y) { inner(y, "s") };
+  helper(x * 2)
+};
+outer(1);`
+
+	evaluated := testEval(input)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		function string
+		pos      string
+	}{
+		{"inner", "5:29"},
+		{"helper", "6:9"},
+		{"outer", "8:6"},
+	}
+
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong stack depth. expected=%d, got=%d", len(expected), len(errObj.Stack))
+	}
+
+	for i, tt := range expected {
+		frame := errObj.Stack[i]
+		if frame.Function != tt.function {
+			t.Errorf("frame %d has wrong function. expected=%q, got=%q", i, tt.function, frame.Function)
+		}
+		if frame.Pos.String() != tt.pos {
+			t.Errorf("frame %d has wrong position. expected=%q, got=%q", i, tt.pos, frame.Pos.String())
+		}
+	}
+
+	expectedTraceback := `Traceback (most recent call last):
+  8:6: in outer(1)
+  6:9: in helper(2)
+  5:29: in inner(2, "s")
+`
+	if errObj.Traceback() != expectedTraceback {
+		t.Errorf("wrong traceback.\nexpected=%q\ngot=%q", expectedTraceback, errObj.Traceback())
+	}
+
+	if errObj.Pos.String() != "2:5" {
+		t.Errorf("wrong error position. expected=%q, got=%q", "2:5", errObj.Pos.String())
+	}
+}
+
+func TestEvalLimits(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input    string
+		ctx      context.Context
+		limits   Limits
+		expected string
+	}{
+		{
+			"let f = fn() { f() };\nf()",
+			context.Background(),
+			Limits{},
+			"stack overflow: maximum call depth of 10000 exceeded",
+		},
+		{
+			"let f = fn(n) { if (n > 0) { f(n - 1) } else { n } };\nf(100)",
+			context.Background(),
+			Limits{MaxCallDepth: 50},
+			"stack overflow: maximum call depth of 50 exceeded",
+		},
+		{
+			"let f = fn() { f() };\nf()",
+			context.Background(),
+			Limits{MaxSteps: 100},
+			"step limit exceeded: 100 steps",
+		},
+		{
+			"1 + 2",
+			cancelled,
+			Limits{},
+			"evaluation cancelled: context canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+		env := object.NewEnvironment()
+
+		evaluated := EvalContext(tt.ctx, program, env, tt.limits)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+
+		if env.Budget() != nil {
+			t.Errorf("budget left on the environment after EvalContext returned")
+		}
+	}
+}
+
+func TestEvalWithinLimits(t *testing.T) {
+	input := "let f = fn(n) { if (n > 0) { f(n - 1) } else { n } };\nf(10)"
+
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	evaluated := EvalContext(context.Background(), program, object.NewEnvironment(), Limits{MaxSteps: 1000, MaxCallDepth: 11})
+	testIntegerObject(t, evaluated, 0)
+}
+
+func TestRecursionTraceback(t *testing.T) {
+	evaluated := testEval("let f = fn() { f() };\nf()")
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := `Traceback (most recent call last):
+  2:2: in f()
+  1:17: in f()
+  [previous line repeated 9999 more times]
+`
+	if errObj.Traceback() != expected {
+		t.Errorf("wrong traceback.\nexpected=%q\ngot=%q", expected, errObj.Traceback())
+	}
+}
+
+func TestTailCallDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		limits   Limits
+		expected string
+	}{
+		{
+			"let f = fn(n) { if (n == 0) { n } else { f(n - 1) } };\nf(100000)",
+			Limits{},
+			"stack overflow: maximum call depth of 10000 exceeded",
+		},
+		{
+			"let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };\nlet isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };\nisEven(100)",
+			Limits{MaxCallDepth: 50},
+			"stack overflow: maximum call depth of 50 exceeded",
+		},
+		{
+			"let f = fn() { return f(); };\nf()",
+			Limits{MaxCallDepth: 50},
+			"stack overflow: maximum call depth of 50 exceeded",
+		},
+		{
+			"let f = fn() { f() };\nf()",
+			Limits{MaxSteps: 1000, MaxCallDepth: 1000000},
+			"step limit exceeded: 1000 steps",
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		evaluated := EvalContext(context.Background(), program, object.NewEnvironment(), tt.limits)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("expected an error for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+
+	// the calls before the limit unwind, so the next evaluation starts from depth 0 again
+	program := testParseProgram("let f = fn(n) { if (n == 0) { n } else { f(n - 1) } };\nf(9000); f(9000)")
+	env := object.NewEnvironment()
+	testIntegerObject(t, EvalContext(context.Background(), program, env, Limits{}), 0)
+}
+
+func TestLongTracebackIsTruncated(t *testing.T) {
+	input := `let down = fn(n) { if (n == 0) { n + true } else { 1 + down(n - 1) } };
+down(30);`
+
+	evaluated := testEval(input)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := `Traceback (most recent call last):
+  2:5: in down(30)
+  1:60: in down(29)
+  1:60: in down(28)
+  1:60: in down(27)
+  1:60: in down(26)
+  1:60: in down(25)
+  1:60: in down(24)
+  1:60: in down(23)
+  1:60: in down(22)
+  1:60: in down(21)
+  ... 11 more frames
+  1:60: in down(9)
+  1:60: in down(8)
+  1:60: in down(7)
+  1:60: in down(6)
+  1:60: in down(5)
+  1:60: in down(4)
+  1:60: in down(3)
+  1:60: in down(2)
+  1:60: in down(1)
+  1:60: in down(0)
+`
+	if errObj.Traceback() != expected {
+		t.Errorf("wrong traceback.\nexpected=%q\ngot=%q", expected, errObj.Traceback())
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let sum = fn(n, acc) { if (n == 0) { acc } else { sum(n - 1, acc + n) } };\nsum(100000, 0)", 5000050000},
+		{"let sum = fn(n, acc) { if (n == 0) { return acc; } return sum(n - 1, acc + n); };\nsum(100000, 0)", 5000050000},
+		{"let sum = fn(n, acc) { if (n == 0) { return acc; }; sum(n - 1, acc + n) };\nsum(100000, 0)", 5000050000},
+		{"let count = fn(arr, acc) { if (len(arr) == 0) { acc } else { count(rest(arr), acc + 1) } };\ncount([1, 2, 3, 4], 0)", 4},
+		{`
+let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+if (isEven(50001)) { 1 } else { 0 }`, 0},
+		{"let f = fn(arr) { len(arr) }; f([1, 2])", 2},
+		{"let f = fn(x) { fn(y) { x + y } }; f(1)(2)", 3},
+	}
+
+	// tail calls count against the call depth like any other, with the limit raised this far each of
+	// these only works because they run in constant Go stack
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		evaluated := EvalContext(context.Background(), program, object.NewEnvironment(), Limits{MaxCallDepth: 1000000})
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestTailCallStackTrace(t *testing.T) {
+	input := `let down = fn(n) { if (n == 0) { n + true } else { down(n - 1) } };
+down(2);`
+
+	evaluated := testEval(input)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expectedTraceback := `Traceback (most recent call last):
+  2:5: in down(2)
+  1:56: in down(1)
+  1:56: in down(0)
+`
+	if errObj.Traceback() != expectedTraceback {
+		t.Errorf("wrong traceback.\nexpected=%q\ngot=%q", expectedTraceback, errObj.Traceback())
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 5) { let i = i + 1; }; i", 5},
+		{"let i = 0; while (true) { let i = i + 1; if (i == 3) { break; } }; i", 3},
+		{"let sum = 0; for (let i = 0; i < 5; let i = i + 1) { if (i == 2) { continue; } let sum = sum + i; }; sum", 8},
+		{"let n = 0; for (;;) { let n = n + 1; if (n > 3) { break; } }; n", 4},
+		{"let sum = 0; for (x in [1, 2, 3]) { let sum = sum + x; }; sum", 6},
+		{"let sum = 0; for (i, x in [10, 20, 30]) { let sum = sum + i * x; }; sum", 80},
+		{`let out = ""; for (c in "abc") { let out = c + out; }; out`, "cba"},
+		{`let out = ""; for (k in {"b": 1, "a": 2}) { let out = out + k; }; out`, "ba"},
+		{`let sum = 0; for (k, v in {"b": 1, "a": 2}) { let sum = sum + v; }; sum`, 3},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x * 10; } } }; f()", 20},
+		{"let f = fn() { while (true) { return 1; } }; f()", 1},
+		{"let n = 0; for (x in [1, 2]) { for (y in [1, 2, 3]) { if (y == 2) { break; } let n = n + 1; } }; n", 2},
+		{"while (false) { 1 }", nil},
+		{"for (x in 5) { x }", "cannot iterate over INTEGER"},
+		{"while (1 + true) { 1 }", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+				continue
+			}
+
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+			}
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; x = 5; x", 5},
+		{"let x = 1; x = 5", 5},
+		{"let x = 2; x += 3; x *= 4; x -= 1; x /= 3; x", 6},
+		{"let a = 0; let b = 0; a = b = 7; a + b", 14},
+		{"let n = 0; let inc = fn() { n += 1 }; inc(); inc(); n", 2},
+		{"let n = 0; let f = fn() { let n = 10; n = 20 }; f(); n", 0},
+		{"let i = 0; while (i < 5) { i += 1 }; i", 5},
+		{"let sum = 0; for (let i = 0; i < 4; i += 1) { sum += i }; sum", 6},
+		{"let xs = [1, 2, 3]; xs[1] = 9; xs[2] += 1; xs[0] + xs[1] + xs[2]", 14},
+		{`let h = {"a": 1}; h["a"] += 1; h["b"] = 5; h["a"] + h["b"]`, 7},
+		{`let s = "a"; s += "b"; s`, "ab"},
+		{"y = 1", "cannot assign to undeclared identifier: y"},
+		{"y += 1", "cannot assign to undeclared identifier: y"},
+		{"let x = 1; x += true", "type mismatch: INTEGER + BOOLEAN"},
+		{"let xs = [1]; xs[1] = 2", "index out of range: 1"},
+		{`let xs = [1]; xs["0"] = 2`, "array index must be INTEGER, got STRING"},
+		{`let h = {}; h["a"] += 1`, "key not found: a"},
+		{"let h = {}; h[fn(x) { x }] = 1", "unusable as hash key: FUNCTION"},
+		{`let s = "abc"; s[0] = "z"`, "index assignment not supported: STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+				continue
+			}
+
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+			}
+		}
+	}
+}
+
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808"},
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+		{"123456789012345678901234567890 / 10", "12345678901234567890123456789"},
+		{"-123456789012345678901234567890 / 7", "-17636684144620811271604938270"},
+		{"(9223372036854775807 + 1) - 1", "9223372036854775807"},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(25)", "15511210043330985984000000"},
+		{"99999999999999999999 > 1", "true"},
+		{"99999999999999999999 == 99999999999999999999", "true"},
+		{"99999999999999999999 != 99999999999999999999 + 1", "true"},
+		{"99999999999999999999 * 1.0", "1e+20"},
+		{`let h = {99999999999999999999: "big", 1: "small"}; h[99999999999999999998 + 1] + h[(99999999999999999999 - 99999999999999999998)]`, "bigsmall"},
+		{`let h = {18446744073709551616: "big"}; h[-1300789964862373523]`, "null"},
+		{"[1, 2][99999999999999999999]", "null"},
+		{`int("-99999999999999999999")`, "-99999999999999999999"},
+		{"int(1e20)", "100000000000000000000"},
+		{"float(99999999999999999999)", "1e+20"},
+		{"99999999999999999999 / 0", "ERROR: 1:22: division by zero"},
+		{"99999999999999999999 + true", "ERROR: 1:22: type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	// a result that fits again goes back to an ordinary Integer
+	testIntegerObject(t, testEval("(9223372036854775807 + 10) - 10"), 9223372036854775807)
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 && 2", 2},
+		{"0 || 5", 0},
+		{`false || "fallback"`, "fallback"},
+		{`first([]) || "default"`, "default"},
+		{"first([]) && 1", nil},
+		{"false && undefined", false},
+		{"true || undefined", true},
+		{"let n = 0; let bump = fn() { n += 1; true }; false && bump(); true || bump(); n", 0},
+		{"let n = 0; let bump = fn() { n += 1; true }; true && bump(); false || bump(); n", 2},
+		{"1 < 2 && 2 < 3", true},
+		{"true && undefined", "identifier not found: undefined"},
+		{"7.5 % 2", 1.5},
+		{"5 % 0", "division by zero"},
+		{"99999999999999999999 % 7", 1},
+		{`"a" % "b"`, "unknown operator: STRING % STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+				continue
+			}
+
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+			}
+		}
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let add = fn(a, b = 10) { a + b }; add(1)", 11},
+		{"let add = fn(a, b = 10) { a + b }; add(1, 2)", 3},
+		{"let f = fn(a, b = a * 2) { b }; f(4)", 8},
+		{"let n = 0; let f = fn(a = n += 1) { a }; f(); f(); f(100); n", 2},
+		{"let f = fn(...xs) { len(xs) }; f()", 0},
+		{"let f = fn(first, ...rest) { len(rest) }; f(1, 2, 3)", 2},
+		{"let f = fn(first, ...rest) { rest[1] }; f(1, 2, 3)", 3},
+		{"let f = fn(a, b = 2, ...rest) { a + b + len(rest) }; f(1)", 3},
+		{"let f = fn(a, b = 2, ...rest) { a + b + len(rest) }; f(1, 5, 0, 0)", 8},
+		{"let f = fn(a, b) { a }; f(1)", "wrong number of arguments: want=2, got=1"},
+		{"let f = fn(a) { a }; f(1, 2)", "wrong number of arguments: want=1, got=2"},
+		{"let f = fn(a, b = 1) { a }; f()", "wrong number of arguments: want=1 to 2, got=0"},
+		{"let f = fn(a, b = 1) { a }; f(1, 2, 3)", "wrong number of arguments: want=1 to 2, got=3"},
+		{"let f = fn(a, b, ...c) { a }; f(1)", "wrong number of arguments: want=at least 2, got=1"},
+		{"let f = fn(a = missing) { a }; f()", "identifier not found: missing"},
+		{"let f = fn(a) { a }; let g = fn() { f() }; g()", "wrong number of arguments: want=1, got=0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	// the error points at the call, also when the call is in tail position
+	evaluated := testEval("let f = fn(a) { a };\nlet g = fn() { f() };\ng()")
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Pos.String() != "2:17" {
+		t.Errorf("wrong error. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let [a, b] = [1, 2]; a + b", 3},
+		{"let [a, b, c] = [1, 2]; c", nil},
+		{"let [a] = [1, 2, 3]; a", 1},
+		{"let [a, ...rest] = [1, 2, 3]; len(rest) + rest[1]", 5},
+		{"let [a, b, ...rest] = [1]; len(rest)", 0},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b + c", 6},
+		{"let [a, [b, c]] = [1]; c", nil},
+		{`let {"name": n, "age": a} = {"name": "ann", "age": 30}; a`, 30},
+		{`let {"name": n, "age": a} = {"name": "ann"}; a`, nil},
+		{`let k = "x"; let {k: v} = {"x": 7}; v`, 7},
+		{`let {"pos": [x, y]} = {"pos": [3, 4]}; x * y`, 12},
+		{`let [{"id": id}, ...others] = [{"id": 9}, {"id": 8}]; id + len(others)`, 10},
+		{`let {"inner": {"deep": d}} = {}; d`, nil},
+		{"let [a, b] = 5;", "cannot destructure INTEGER with an array pattern"},
+		{`let {"a": a} = [1];`, "cannot destructure ARRAY with a hash pattern"},
+		{"let {[1]: a} = {};", "unusable as hash key: ARRAY"},
+		{"let [a, b] = missing;", "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestStringsNamespace(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`strings["split"]("a,b,,c", ",")`, []string{"a", "b", "", "c"}},
+		{`strings["split"]("héj", "")`, []string{"h", "é", "j"}},
+		{`strings["join"](["a", "b", "c"], "-")`, "a-b-c"},
+		{`strings["join"]([], "-")`, ""},
+		{`strings["trim"]("  hi\n")`, "hi"},
+		{`strings["replace"]("a-b-c", "-", "+")`, "a+b+c"},
+		{`strings["contains"]("monkey", "key")`, true},
+		{`strings["contains"]("monkey", "Key") == false`, true},
+		{`strings["starts_with"]("monkey", "mon")`, true},
+		{`strings["ends_with"]("monkey", "mon")`, false},
+		{`strings["index_of"]("monkey", "key")`, 3},
+		{`strings["index_of"]("héllo", "l")`, 2},
+		{`strings["index_of"]("monkey", "z")`, -1},
+		{`strings["upper"]("Monkey")`, "MONKEY"},
+		{`strings["lower"]("Monkey")`, "monkey"},
+		{`strings["repeat"]("ab", 3)`, "ababab"},
+		{`strings["repeat"]("ab", 0)`, ""},
+		{`strings["substr"]("monkey", 3)`, "key"},
+		{`strings["substr"]("monkey", 1, 3)`, "onk"},
+		{`strings["substr"]("héllo", 1, 100)`, "éllo"},
+		{`strings["substr"]("monkey", 6)`, ""},
+		{`strings["substr"]("abc", 1, 9223372036854775807)`, "bc"},
+		{`let upper = strings["upper"]; upper("x")`, "X"},
+		{`strings["split"]("a", 1)`, errorMessage("argument to `strings.split` must be STRING, got INTEGER")},
+		{`strings["upper"]()`, errorMessage("wrong number of arguments. got=0, expected=1")},
+		{`strings["join"](["a", 1], "")`, errorMessage("`strings.join` can only join STRING elements, got INTEGER")},
+		{`strings["join"]("a", "")`, errorMessage("argument to `strings.join` must be ARRAY, got STRING")},
+		{`strings["repeat"]("a", -1)`, errorMessage("`strings.repeat` count must not be negative, got -1")},
+		{`strings["repeat"]("ab", 9223372036854775807)`, errorMessage("`strings.repeat` result too long: 9223372036854775807 times 2 bytes")},
+		{`strings["repeat"]("ab", 1073741824)`, errorMessage("`strings.repeat` result too long: 1073741824 times 2 bytes")},
+		{`strings["repeat"]("a", "3")`, errorMessage("argument to `strings.repeat` must be INTEGER, got STRING")},
+		{`strings["substr"]("abc", 4)`, errorMessage("`strings.substr` start out of range: 4")},
+		{`strings["substr"]("abc", 0, -1)`, errorMessage("`strings.substr` length must not be negative, got -1")},
+		{`strings["substr"]("abc")`, errorMessage("wrong number of arguments. got=1, expected=2 or 3")},
+		{`strings["reverse"]`, errorMessage("module strings has no export reverse")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		testExpectedObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestCollectionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"map([1, 2, 3], fn(x) { x * 2 })", []int64{2, 4, 6}},
+		{"map([], fn(x) { x })", []int64{}},
+		{"let xs = [1, 2]; map(xs, fn(x) { x + 1 }); xs", []int64{1, 2}},
+		{"filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })", []int64{2, 4}},
+		{"reduce([1, 2, 3, 4], fn(acc, x) { acc + x })", 10},
+		{"reduce([1, 2, 3], fn(acc, x) { acc * x }, 10)", 60},
+		{"reduce([], fn(acc, x) { acc + x }, 0)", 0},
+		{"let total = 0; each([1, 2, 3], fn(x) { total += x }); total", 6},
+		{"find([1, 5, 7], fn(x) { x > 3 })", 5},
+		{"find([1, 2], fn(x) { x > 3 })", nil},
+		{"any([1, 2, 3], fn(x) { x > 2 })", true},
+		{"any([], fn(x) { true })", false},
+		{"all([1, 2, 3], fn(x) { x > 0 })", true},
+		{"all([1, 2, 3], fn(x) { x > 1 })", false},
+		{"sort([3, 1, 2])", []int64{1, 2, 3}},
+		{"sort([2, 1.5, 1])[1]", 1.5},
+		{"sort([3, 1, 2], fn(a, b) { a > b })", []int64{3, 2, 1}},
+		{`sort(["b", "c", "a"])[0]`, "a"},
+		// equal elements keep their order
+		{"map(sort([[1, 0], [0, 1], [1, 2], [0, 3]], fn(a, b) { a[0] < b[0] }), fn(p) { p[1] })", []int64{1, 3, 0, 2}},
+		{"let xs = [2, 1]; sort(xs); xs", []int64{2, 1}},
+		{"map([1, 2], len)", errorMessage("argument to `len` not supported, got INTEGER")},
+		{"map([1], fn(x) { x + true })", errorMessage("type mismatch: INTEGER + BOOLEAN")},
+		{"map([1], fn(a, b) { a })", errorMessage("wrong number of arguments: want=2, got=1")},
+		{"map(1, fn(x) { x })", errorMessage("argument to `map` must be ARRAY, got INTEGER")},
+		{"filter([1], 1)", errorMessage("argument to `filter` must be FUNCTION, got INTEGER")},
+		{"reduce([], fn(acc, x) { acc })", errorMessage("`reduce` of an empty array needs an initial value")},
+		{`sort([1, "a"])`, errorMessage("`sort` can't compare STRING and INTEGER without a comparator")},
+		{"sort([2, 1], fn(a, b) { a < missing })", errorMessage("identifier not found: missing")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		testExpectedObject(t, tt.input, evaluated, tt.expected)
+	}
+
+	// a callback counts against the limits of the evaluation calling the builtin
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New("map([1, 2, 3], fn(x) { let i = 0; while (true) { i += 1 } })")).ParseProgram()
+	evaluated := EvalContext(context.Background(), program, env, Limits{ MaxSteps: 500 })
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != "step limit exceeded: 500 steps" {
+		t.Errorf("expected the step limit to be exceeded. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`keys({"b": 2, "a": 1, "c": 3})`, inspected(`[b, a, c]`)},
+		{`values({"b": 2, "a": 1, "c": 3})`, inspected(`[2, 1, 3]`)},
+		{`entries({"b": 2, "a": 1})`, inspected(`[[b, 2], [a, 1]]`)},
+		{`keys({})`, inspected(`[]`)},
+		{`has({"a": first([])}, "a")`, true},
+		{`has({"a": 1}, "b")`, false},
+		{`has({1: 1}, 1)`, true},
+		{`size({"a": 1, "b": 2})`, 2},
+		{`size({})`, 0},
+		{`delete({"a": 1, "b": 2}, "a")`, inspected(`{b: 2}`)},
+		{`delete({"a": 1}, "z")`, inspected(`{a: 1}`)},
+		{`let h = {"a": 1}; delete(h, "a"); h`, inspected(`{a: 1}`)},
+		{`merge({"a": 1, "b": 2}, {"b": 3}, {"c": 4})`, inspected(`{a: 1, b: 3, c: 4}`)},
+		{`let h = {"a": 1}; merge(h, {"a": 2}); h`, inspected(`{a: 1}`)},
+		{`merge({"b": 1, "a": 2}, {"c": 3, "b": 4})`, inspected(`{b: 4, a: 2, c: 3}`)},
+		{`merge(delete({"a": 1, "b": 2}, "a"), {"a": 3})`, inspected(`{b: 2, a: 3}`)},
+		{`keys([1])`, errorMessage("argument to `keys` must be HASH, got ARRAY")},
+		{`has({}, [1])`, errorMessage("unusable as hash key: ARRAY")},
+		{`delete({}, fn() {})`, errorMessage("unusable as hash key: FUNCTION")},
+		{`merge({}, 1)`, errorMessage("argument to `merge` must be HASH, got INTEGER")},
+		{`merge()`, errorMessage("wrong number of arguments. got=0, expected at least 1")},
+		{`size({}, {})`, errorMessage("wrong number of arguments. got=2, expected=1")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		testExpectedObject(t, tt.input, evaluated, tt.expected)
+	}
+}
@@ -0,0 +1,135 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"os"
+	"path/filepath"
+)
+
+// An import parses and evaluates another file in an environment of its own and evaluates to a module of
+// the bindings that file declares with export let. Every file is only evaluated once per Modules, so
+// importing it again, from anywhere, gives the same module.
+
+func evalImportExpression(ie *ast.ImportExpression, env *object.Environment) object.Object {
+	modules := env.Modules()
+	if modules == nil {
+		modules = object.NewModules()
+		env.SetModules(modules)
+	}
+
+	// relative imports start from the importing file, or the working directory when there's no file
+	dir := ""
+	if filename := ie.Token.Pos.Filename; filename != "" {
+		dir = filepath.Dir(filename)
+	}
+
+	path, ok := modules.Resolve(ie.Path.Value, dir)
+	if !ok {
+		return newError("module not found: %s", ie.Path.Value)
+	}
+
+	if module, ok := modules.Loaded(path); ok {
+		return module
+	}
+
+	if err := modules.Begin(path); err != nil {
+		return err
+	}
+
+	module, err := loadModule(ie.Path.Value, path, env)
+	modules.End(path, module)
+
+	if err != nil {
+		return err
+	}
+	return module
+}
+
+func loadModule(name string, path string, env *object.Environment) (*object.Module, *object.Error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newError("cannot import %s: %s", name, err)
+	}
+
+	p := parser.New(lexer.NewFile(path, string(source)))
+	program := p.ParseProgram()
+
+	if errors := p.Errors(); len(errors) != 0 {
+		return nil, newError("cannot import %s: %s", name, errors[0])
+	}
+
+	macroEnv := object.NewModuleEnvironment(env)
+	DefineMacros(program, macroEnv)
+	expanded, errObj := ExpandMacros(program, macroEnv)
+	if errObj != nil {
+		return nil, errObj
+	}
+
+	moduleEnv := object.NewModuleEnvironment(env)
+	if errObj, ok := Eval(expanded, moduleEnv).(*object.Error); ok {
+		return nil, errObj
+	}
+
+	module := &object.Module{ Name: name, Path: path, Exports: make(map[string]object.Object) }
+	for _, statement := range expanded.(*ast.Program).Statements {
+		let, ok := statement.(*ast.LetStatement)
+		if !ok || !let.Exported {
+			continue
+		}
+
+		for _, ident := range boundIdentifiers(let) {
+			if val, ok := moduleEnv.Get(ident.Value); ok {
+				module.Exports[ident.Value] = val
+			}
+		}
+	}
+
+	return module, nil
+}
+
+// boundIdentifiers lists the names a let statement binds, all of them for a destructuring let.
+func boundIdentifiers(let *ast.LetStatement) []*ast.Identifier {
+	if let.Pattern == nil {
+		return []*ast.Identifier{let.Name}
+	}
+
+	return patternIdentifiers(let.Pattern, nil)
+}
+
+func patternIdentifiers(pattern ast.Expression, idents []*ast.Identifier) []*ast.Identifier {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		idents = append(idents, pattern)
+	case *ast.ArrayPattern:
+		for _, element := range pattern.Elements {
+			idents = patternIdentifiers(element, idents)
+		}
+		if pattern.Rest != nil {
+			idents = append(idents, pattern.Rest)
+		}
+	case *ast.HashPattern:
+		// only the values bind names, the keys are expressions
+		for _, value := range pattern.Values {
+			idents = patternIdentifiers(value, idents)
+		}
+	}
+
+	return idents
+}
+
+func evalModuleIndexExpression(module *object.Module, index object.Object) object.Object {
+	name, ok := index.(*object.String)
+	if !ok {
+		return newError("module index must be STRING, got %s", index.Type())
+	}
+
+	val, ok := module.Exports[name.Value]
+	if !ok {
+		return newError("module %s has no export %s", module.Name, name.Value)
+	}
+
+	return val
+}
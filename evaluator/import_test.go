@@ -0,0 +1,111 @@
+package evaluator
+
+import (
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModules writes files, keyed by their path relative to a new temporary directory, and returns the directory.
+func writeModules(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// testEvalFile evaluates input as if it was read from path, so imports in it are relative to path's directory.
+func testEvalFile(path string, input string, modules *object.Modules) object.Object {
+	p := parser.New(lexer.NewFile(path, input))
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	env.SetModules(modules)
+
+	return Eval(program, env)
+}
+
+func TestImports(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"math.mk": `
+			let helper = fn(x) { x * 2 };
+			export let double = fn(x) { helper(x) };
+			export let [one, two] = [1, 2];
+			export let {"three": three} = {"three": 3};
+			export let loads = 0;
+			loads += 1;
+		`,
+		"lib/strings.mk": `
+			let m = import "../math.mk";
+			export let twice = fn(s) { s + s };
+			export let four = m["double"](m["two"]);
+		`,
+		"vendor/extra.mk": `export let answer = 42;`,
+		"broken.mk":       `let x = ;`,
+		"failing.mk":      "export let x = 1;\nlet y = missing;",
+		"cycle/a.mk":      `import "b.mk";`,
+		"cycle/b.mk":      `import "a.mk";`,
+	})
+	main := filepath.Join(dir, "main.mk")
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let m = import "math.mk"; m["double"](5)`, 10},
+		{`let m = import "math.mk"; m["one"] + m["two"] + m["three"]`, 6},
+		{`let s = import "lib/strings.mk"; s["four"]`, 4},
+		{`let s = import "lib/strings.mk"; s["twice"]("ab")`, "abab"},
+		{`(import "extra.mk")["answer"]`, 42},
+		{`let a = import "math.mk"; let b = import "lib/strings.mk"; let c = import "math.mk"; c["loads"]`, 1},
+		{`let m = import "math.mk"; m["helper"]`, errorMessage("module math.mk has no export helper")},
+		{`let m = import "math.mk"; m[1]`, errorMessage("module index must be STRING, got INTEGER")},
+		{`import "nope.mk"`, errorMessage("module not found: nope.mk")},
+		{`import "broken.mk"`, errorMessage("cannot import broken.mk: " + filepath.Join(dir, "broken.mk") + ":1:9: No prefix parser function found for ;")},
+		{`import "failing.mk"`, errorMessage("identifier not found: missing")},
+		{`import "cycle/a.mk"`, errorMessage("import cycle: " + strings.Join([]string{
+			filepath.Join(dir, "cycle", "a.mk"), filepath.Join(dir, "cycle", "b.mk"), filepath.Join(dir, "cycle", "a.mk"),
+		}, " -> "))},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalFile(main, tt.input, object.NewModules(filepath.Join(dir, "vendor")))
+
+		testExpectedObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestImportCache(t *testing.T) {
+	dir := writeModules(t, map[string]string{"counter.mk": `export let count = 0; export let inc = fn() { count += 1 };`})
+	modules := object.NewModules()
+
+	// the second import, here of the same file through another relative path, shares the first one's state
+	evaluated := testEvalFile(filepath.Join(dir, "main.mk"), `
+		let a = import "counter.mk";
+		let b = import "./sub/../counter.mk";
+		a["inc"](); b["inc"]();
+		b["inc"]()
+	`, modules)
+	testIntegerObject(t, evaluated, 3)
+
+	module, ok := modules.Loaded(filepath.Join(dir, "counter.mk"))
+	if !ok {
+		t.Fatalf("counter.mk is not cached")
+	}
+	if module.Name != "counter.mk" {
+		t.Errorf("module.Name wrong. want=%q, got=%q", "counter.mk", module.Name)
+	}
+}
@@ -0,0 +1,122 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// Loops are statements, they evaluate to null. Their variables live in the enclosing environment, the
+// same as a let statement inside an if block does.
+
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(ws.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		if result, done := evalLoopBody(ws.Body, env); done {
+			return result
+		}
+	}
+}
+
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	if fs.Init != nil {
+		if init := Eval(fs.Init, env); isError(init) {
+			return init
+		}
+	}
+
+	for {
+		if fs.Condition != nil {
+			condition := Eval(fs.Condition, env)
+			if isError(condition) {
+				return condition
+			}
+
+			if !isTruthy(condition) {
+				return NULL
+			}
+		}
+
+		if result, done := evalLoopBody(fs.Body, env); done {
+			return result
+		}
+
+		if fs.Post != nil {
+			if post := Eval(fs.Post, env); isError(post) {
+				return post
+			}
+		}
+	}
+}
+
+func evalForInStatement(fi *ast.ForInStatement, env *object.Environment) object.Object {
+	iterable := Eval(fi.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	var keys, values []object.Object
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		for i, el := range iterable.Elements {
+			keys = append(keys, &object.Integer{ Value: int64(i) })
+			values = append(values, el)
+		}
+
+	case *object.String:
+		for i, ch := range []rune(iterable.Value) {
+			keys = append(keys, &object.Integer{ Value: int64(i) })
+			values = append(values, &object.String{ Value: string(ch) })
+		}
+
+	case *object.Hash:
+		for _, pair := range iterable.OrderedPairs() {
+			keys = append(keys, pair.Key)
+			values = append(values, pair.Value)
+		}
+
+		// A single name gets the keys of a hash, not its values
+		if fi.Key == nil {
+			values = keys
+		}
+
+	default:
+		return newError("cannot iterate over %s", iterable.Type())
+	}
+
+	for i := range values {
+		if fi.Key != nil {
+			env.Set(fi.Key.Value, keys[i])
+		}
+		env.Set(fi.Value.Value, values[i])
+
+		if result, done := evalLoopBody(fi.Body, env); done {
+			return result
+		}
+	}
+
+	return NULL
+}
+
+// evalLoopBody runs one iteration of a loop. It reports done when the loop has to stop, with the
+// result the loop evaluates to: null after a break, or the error or return value that ended it.
+func evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	result := Eval(body, env)
+
+	switch result := result.(type) {
+	case *object.Break:
+		return NULL, true
+	case *object.ReturnValue, *object.Error:
+		return result, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,213 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+	let number = 1;
+	let function = fn(x, y) { x + y };
+	let mymacro = macro(x, y) { x + y; };
+	`
+
+	env := object.NewEnvironment()
+	program := testParseProgram(input)
+
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("Wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	_, ok := env.Get("number")
+	if ok {
+		t.Fatalf("number should not be defined")
+	}
+	_, ok = env.Get("function")
+	if ok {
+		t.Fatalf("function should not be defined")
+	}
+
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in environment.")
+	}
+
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("object is not Macro. got=%T (%+v)", obj, obj)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("Wrong number of macro parameters. got=%d", len(macro.Parameters))
+	}
+
+	if macro.Parameters[0].String() != "x" {
+		t.Fatalf("parameter is not 'x'. got=%q", macro.Parameters[0])
+	}
+	if macro.Parameters[1].String() != "y" {
+		t.Fatalf("parameter is not 'y'. got=%q", macro.Parameters[1])
+	}
+
+	expectedBody := "(x + y)"
+
+	if macro.Body.String() != expectedBody {
+		t.Fatalf("body is not %q. got=%q", expectedBody, macro.Body.String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input 		string
+		expected 	string
+	}{
+		{
+			`
+			let infixExpression = macro() { quote(1 + 2); };
+
+			infixExpression();
+			`,
+			`(1 + 2)`,
+		},
+		{
+			`
+			let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+
+			reverse(2 + 2, 10 - 5);
+			`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`
+			let unless = macro(condition, consequence, alternative) {
+				quote(if (!(unquote(condition))) {
+					unquote(consequence);
+				} else {
+					unquote(alternative);
+				});
+			};
+
+			unless(10 > 5, puts("not greater"), puts("greater"));
+			`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+	}
+
+	for _, tt := range tests {
+		expected := testParseProgram(tt.expected)
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("unexpected expansion error: %s", err.Inspect())
+		}
+
+		if expanded.String() != expected.String() {
+			t.Errorf("not equal. want=%q, got=%q", expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []struct {
+		input 		string
+		expected 	string
+	}{
+		{
+			"let m = macro(x) { 1 };\nm(1);",
+			"ERROR: 2:2: macro must return a quote, got INTEGER",
+		},
+		{
+			"let m = macro(x) { let y = 1; };\nm(1);",
+			"ERROR: 2:2: macro must return a quote, got NULL",
+		},
+		{
+			"let m = macro() { };\nm();",
+			"ERROR: 2:2: macro must return a quote, got NULL",
+		},
+		{
+			"let m = macro(x) { quote(x) };\nm(1, 2);",
+			"ERROR: 2:2: wrong number of arguments: want=1, got=2",
+		},
+		{
+			"let m = macro(x) { quote(unquote(x) + unquote(nope)) };\nm(1);",
+			"ERROR: 1:47: identifier not found: nope",
+		},
+		{
+			"let m = macro(x) { quote(unquote(fn() { 1 })) };\nm(1);",
+			"ERROR: 1:33: cannot unquote FUNCTION",
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		_, err := ExpandMacros(program, env)
+		if err == nil {
+			t.Errorf("expected an expansion error for %q", tt.input)
+			continue
+		}
+
+		if err.Inspect() != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, err.Inspect())
+		}
+	}
+}
+
+func TestMacroLiteralOutsideLet(t *testing.T) {
+	inputs := []string{
+		"puts(macro(a) { a })",
+		"let f = fn() { macro(a) { a } }; f()",
+		"if (true) { let m = macro(a) { a }; m }",
+	}
+
+	for _, input := range inputs {
+		evaluated := testEval(input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("expected *object.Error for %s. got=%T (%+v)", input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != "macro literal is only allowed in a top-level let" {
+			t.Errorf("wrong error message for %s. got=%q", input, errObj.Message)
+		}
+	}
+}
+
+func TestUnquotedNullAndCollections(t *testing.T) {
+	// a null of the script's own doesn't change what the unquoted null is
+	input := `
+	let wrap = macro(x) { quote([unquote(first([])), unquote([1, 2]), unquote(x)]) };
+	let null = 5;
+	wrap(3)
+	`
+
+	program := testParseProgram(input)
+	env := object.NewEnvironment()
+	DefineMacros(program, env)
+	expanded, err := ExpandMacros(program, env)
+	if err != nil {
+		t.Fatalf("unexpected expansion error: %s", err.Inspect())
+	}
+
+	evaluated := Eval(expanded, object.NewEnvironment())
+	if evaluated.Inspect() != "[null, [1, 2], 3]" {
+		t.Errorf("wrong result. got=%s", evaluated.Inspect())
+	}
+}
+
+func testParseProgram(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
@@ -0,0 +1,150 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+	"monkey/token"
+)
+
+func quote(node ast.Node, env *object.Environment) object.Object {
+	node, err := evalUnquoteCalls(node, env)
+	if err != nil {
+		return err
+	}
+	return &object.Quote{ Node: node }
+}
+
+// evalUnquoteCalls replaces every unquote(...) call inside a quoted node with the AST of its evaluated
+// argument. The first unquote that fails stops the quote with its error.
+func evalUnquoteCalls(quoted ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var err *object.Error
+
+	node := ast.Modify(quoted, func(node ast.Node) ast.Node {
+		if err != nil || !isUnquoteCall(node) {
+			return node
+		}
+
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+
+		if len(call.Arguments) != 1 {
+			err = newError("wrong number of arguments. got=%d, expected=1", len(call.Arguments))
+			err.Pos = call.Pos()
+			return node
+		}
+
+		unquoted := Eval(call.Arguments[0], env)
+		if errObj, ok := unquoted.(*object.Error); ok {
+			err = errObj
+			return node
+		}
+
+		converted, convErr := convertObjectToASTNode(unquoted, call.Pos())
+		if convErr != nil {
+			err = convErr
+			return node
+		}
+		return converted
+	})
+
+	return node, err
+}
+
+func isUnquoteCall(node ast.Node) bool {
+	callExpression, ok := node.(*ast.CallExpression)
+	if !ok {
+		return false
+	}
+
+	return callExpression.Function.TokenLiteral() == "unquote"
+}
+
+// convertObjectToASTNode turns a value back into code, the new node takes the position of the unquote
+// call it replaces so errors in expanded code still point somewhere sensible. Values that can't be
+// written as code, like functions, are an error.
+func convertObjectToASTNode(obj object.Object, pos token.Position) (ast.Node, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		t := token.Token{ Type: token.INT, Literal: fmt.Sprintf("%d", obj.Value), Pos: pos }
+		return &ast.IntegerLiteral{ Token: t, Value: obj.Value }, nil
+
+	case *object.BigInteger:
+		t := token.Token{ Type: token.INT, Literal: obj.Inspect(), Pos: pos }
+		return &ast.IntegerLiteral{ Token: t, Big: obj.Value }, nil
+
+	case *object.Float:
+		t := token.Token{ Type: token.FLOAT, Literal: obj.Inspect(), Pos: pos }
+		return &ast.FloatLiteral{ Token: t, Value: obj.Value }, nil
+
+	case *object.Boolean:
+		var t token.Token
+		if obj.Value {
+			t = token.Token{ Type: token.TRUE, Literal: "true", Pos: pos }
+		} else {
+			t = token.Token{ Type: token.FALSE, Literal: "false", Pos: pos }
+		}
+		return &ast.Boolean{ Token: t, Value: obj.Value }, nil
+
+	case *object.String:
+		t := token.Token{ Type: token.STRING, Literal: obj.Value, Pos: pos }
+		return &ast.StringLiteral{ Token: t, Value: obj.Value }, nil
+
+	case *object.Null:
+		return &ast.Null{ Token: token.Token{ Type: token.IDENT, Literal: "null", Pos: pos } }, nil
+
+	case *object.Array:
+		array := &ast.ArrayLiteral{ Token: token.Token{ Type: token.LBRACKET, Literal: "[", Pos: pos } }
+		array.Elements = make([]ast.Expression, len(obj.Elements))
+		for i, element := range obj.Elements {
+			node, err := convertObjectToExpression(element, pos)
+			if err != nil {
+				return nil, err
+			}
+			array.Elements[i] = node
+		}
+		return array, nil
+
+	case *object.Hash:
+		hash := &ast.HashLiteral{ Token: token.Token{ Type: token.LBRACE, Literal: "{", Pos: pos } }
+		hash.Pairs = []ast.HashPair{}
+		for _, pair := range obj.OrderedPairs() {
+			key, err := convertObjectToExpression(pair.Key, pos)
+			if err != nil {
+				return nil, err
+			}
+			value, err := convertObjectToExpression(pair.Value, pos)
+			if err != nil {
+				return nil, err
+			}
+			hash.Pairs = append(hash.Pairs, ast.HashPair{ Key: key, Value: value })
+		}
+		return hash, nil
+
+	case *object.Quote:
+		return obj.Node, nil
+
+	default:
+		err := newError("cannot unquote %s", obj.Type())
+		err.Pos = pos
+		return nil, err
+	}
+}
+
+// convertObjectToExpression converts an element of an array or hash, which has to be an expression.
+func convertObjectToExpression(obj object.Object, pos token.Position) (ast.Expression, *object.Error) {
+	node, err := convertObjectToASTNode(obj, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	expression, ok := node.(ast.Expression)
+	if !ok {
+		err := newError("cannot unquote %s inside an array or hash", node.String())
+		err.Pos = pos
+		return nil, err
+	}
+	return expression, nil
+}
@@ -0,0 +1,128 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input 		string
+		expected 	string
+	}{
+		{ `quote(5)`, `5` },
+		{ `quote(5 + 8)`, `(5 + 8)` },
+		{ `quote(foobar)`, `foobar` },
+		{ `quote(foobar + barfoo)`, `(foobar + barfoo)` },
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+
+		if quote.Node == nil {
+			t.Fatalf("quote.Node is nil")
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []struct {
+		input 		string
+		expected 	string
+	}{
+		{ `quote(unquote(4))`, `4` },
+		{ `quote(unquote(4 + 4))`, `8` },
+		{ `quote(8 + unquote(4 + 4))`, `(8 + 8)` },
+		{ `quote(unquote(4 + 4) + 8)`, `(8 + 8)` },
+		{ `let foobar = 8; quote(foobar)`, `foobar` },
+		{ `let foobar = 8; quote(unquote(foobar))`, `8` },
+		{ `quote(unquote(true))`, `true` },
+		{ `quote(unquote(true == false))`, `false` },
+		{ `quote(unquote(quote(4 + 4)))`, `(4 + 4)` },
+		{ `let quotedInfixExpression = quote(4 + 4); quote(unquote(4 + 4) + unquote(quotedInfixExpression))`, `(8 + (4 + 4))` },
+		{ `quote(unquote("hello"))`, `hello` },
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+
+		if quote.Node == nil {
+			t.Fatalf("quote.Node is nil")
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquoteCollections(t *testing.T) {
+	tests := []struct {
+		input 		string
+		expected 	string
+	}{
+		{ `quote(unquote([1, 2]))`, `[1, 2]` },
+		{ `quote(unquote({"a": [true], 2: "b"}))`, `{a: [true], 2: b}` },
+		{ `quote(unquote(first([])))`, `null` },
+		{ `quote(unquote([quote(1 + 1), 3]))`, `[(1 + 1), 3]` },
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquoteErrors(t *testing.T) {
+	tests := []struct {
+		input 		string
+		expected 	string
+	}{
+		{ `quote()`, "wrong number of arguments. got=0, expected=1" },
+		{ `quote(1, 2)`, "wrong number of arguments. got=2, expected=1" },
+		{ `let f = fn() { quote() }; f()`, "wrong number of arguments. got=0, expected=1" },
+		{ `quote(unquote(nope))`, "identifier not found: nope" },
+		{ `quote(unquote(1) + unquote(nope))`, "identifier not found: nope" },
+		{ `quote(unquote())`, "wrong number of arguments. got=0, expected=1" },
+		{ `quote(unquote(fn(x) { x }))`, "cannot unquote FUNCTION" },
+		{ `quote(unquote([1, len]))`, "cannot unquote BUILTIN" },
+		{ `puts(quote(unquote(nope)))`, "identifier not found: nope" },
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("expected *object.Error for %s. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message for %s. want=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+
+	// inspecting, as puts does, a quote of an unquoted array used to crash on the nil node put in its place
+	if evaluated := testEval(`quote(unquote([1, 2]))`); evaluated.Inspect() != "QUOTE([1, 2])" {
+		t.Errorf("wrong inspect. got=%s", evaluated.Inspect())
+	}
+}
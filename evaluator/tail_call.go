@@ -0,0 +1,88 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+const TAIL_CALL_OBJ = "TAIL_CALL"
+
+// tailCall is a call in tail position of a function body that is still to be made. It never escapes
+// applyFunction, which makes the call in place of the function that returned it, so recursion in tail
+// position runs in constant Go stack.
+type tailCall struct {
+	fn   object.Object
+	args []object.Object
+	call *ast.CallExpression
+}
+
+func (tc *tailCall) Type() object.ObjectType { return TAIL_CALL_OBJ }
+func (tc *tailCall) Inspect() string          { return "tail call" }
+
+// evalTail evaluates node, which is in tail position of a function body: its value, if any, is what the
+// function returns. A call there evaluates to a tailCall, as do calls in the tail position of an if
+// expression, a block or a return statement that is itself in tail position.
+func evalTail(node ast.Node, env *object.Environment) object.Object {
+	return step(node, env, evalTailPosition)
+}
+
+func evalTailPosition(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env, true)
+
+	case *ast.ExpressionStatement:
+		return evalTail(node.Expression, env)
+
+	case *ast.ReturnStatement:
+		val := evalTail(node.ReturnValue, env)
+		if isError(val) {
+			return val
+		}
+		if _, ok := val.(*tailCall); ok {
+			return val
+		}
+		return &object.ReturnValue{ Value: val }
+
+	case *ast.IfExpression:
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if isTruthy(condition) {
+			return evalTail(node.Consequence, env)
+		} else if node.Alternative != nil {
+			return evalTail(node.Alternative, env)
+		} else {
+			return NULL
+		}
+
+	case *ast.CallExpression:
+		if node.Function.TokenLiteral() == "quote" {
+			return eval(node, env)
+		}
+
+		function := Eval(node.Function, env)
+		if isError(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+
+		return &tailCall{ fn: function, args: args, call: node }
+	}
+
+	return eval(node, env)
+}
+
+// addTailCallFrames adds the tail calls a function made to the traceback of an error raised in the last
+// of them, innermost first like addCallFrame.
+func addTailCallFrames(err *object.Error, tailCalls []*tailCall) {
+	for i := len(tailCalls) - 1; i >= 0; i-- {
+		tc := tailCalls[i]
+		addCallFrame(err, tc.call, tc.fn, tc.args)
+	}
+}
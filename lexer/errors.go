@@ -0,0 +1,27 @@
+package lexer
+
+import (
+  "fmt"
+  "monkey/token"
+)
+
+// Error is a problem with the source text itself, like a string that is never closed. The lexer hands
+// out an ILLEGAL token covering the offending text and records an Error explaining it, the parser
+// reports that explanation when it runs into the token.
+type Error struct {
+  Pos     token.Position
+  Message string
+}
+
+func (e *Error) Error() string {
+  return e.Pos.String() + ": " + e.Message
+}
+
+// Errors returns the problems found in the tokens read so far.
+func (lexer *Lexer) Errors() []*Error {
+  return lexer.errors
+}
+
+func (lexer *Lexer) addError(pos token.Position, format string, a ...interface{}) {
+  lexer.errors = append(lexer.errors, &Error{Pos: pos, Message: fmt.Sprintf(format, a...)})
+}
@@ -2,23 +2,39 @@ package lexer
 
 import (
 	"monkey/token"
+	"strings"
+	"unicode/utf8"
 )
 
 type Lexer struct {
   input           string
+  filename        string
   position        int // currnet position in input (current character)
   readPosition    int // current reading position in input (after current char)
   ch              byte // current character under evaluation
+  line            int // line of the current character, starting at 1
+  lineStart       int // position of the first character on the current line
+  errors          []*Error
 }
 
 func New(input string) *Lexer {
-  lexer := &Lexer{input: input}
+  return NewFile("", input)
+}
+
+// NewFile creates a lexer whose token positions carry the given file name.
+func NewFile(filename string, input string) *Lexer {
+  lexer := &Lexer{input: input, filename: filename, line: 1}
   lexer.readChar()
   return lexer
 }
 
 // Reads the next character in the input sequence, sets the lexer to 0 if we reach the end.
 func (lexer *Lexer) readChar() {
+  if lexer.ch == '\n' {
+    lexer.line += 1
+    lexer.lineStart = lexer.readPosition
+  }
+
   if lexer.readPosition >= len(lexer.input) {
     lexer.ch = 0
   } else {
@@ -31,7 +47,14 @@ func (lexer *Lexer) readChar() {
 
 func (lexer *Lexer) NextToken() token.Token {
   var tok token.Token
-  lexer.skipWhitespace()
+  comments, ok := lexer.skipTrivia()
+  pos := lexer.currentPosition()
+
+  if !ok {
+    // the unclosed comment runs to the end of the input, it becomes the last token before EOF
+    last := comments[len(comments)-1]
+    return token.Token{Type: token.ILLEGAL, Literal: last.Text, Pos: last.Pos, Leading: comments[:len(comments)-1]}
+  }
 
   switch lexer.ch  {
     case '=':
@@ -50,7 +73,12 @@ func (lexer *Lexer) NextToken() token.Token {
     case ',':
       tok = newToken(token.COMMA, lexer.ch) 
     case '+':
-      tok = newToken(token.PLUS, lexer.ch) 
+      t, didCreate := lexer.makeTwoCharToken('=', token.PLUS_ASSIGN)
+      if didCreate {
+        tok = t
+      } else {
+        tok = newToken(token.PLUS, lexer.ch)
+      }
     case '{':
       tok = newToken(token.LBRACE, lexer.ch) 
     case '}':
@@ -63,45 +91,112 @@ func (lexer *Lexer) NextToken() token.Token {
         tok = newToken(token.BANG, lexer.ch)
       }
     case '/':
-      tok = newToken(token.SLASH, lexer.ch)
+      t, didCreate := lexer.makeTwoCharToken('=', token.SLASH_ASSIGN)
+      if didCreate {
+        tok = t
+      } else {
+        tok = newToken(token.SLASH, lexer.ch)
+      }
     case '*':
-      tok = newToken(token.ASTERISK, lexer.ch)
+      t, didCreate := lexer.makeTwoCharToken('=', token.ASTERISK_ASSIGN)
+      if didCreate {
+        tok = t
+      } else {
+        tok = newToken(token.ASTERISK, lexer.ch)
+      }
     case '<':
-      tok = newToken(token.LT, lexer.ch)
+      t, didCreate := lexer.makeTwoCharToken('=', token.LT_EQ)
+      if didCreate {
+        tok = t
+      } else {
+        tok = newToken(token.LT, lexer.ch)
+      }
     case '>':
-      tok = newToken(token.GT, lexer.ch)
+      t, didCreate := lexer.makeTwoCharToken('=', token.GT_EQ)
+      if didCreate {
+        tok = t
+      } else {
+        tok = newToken(token.GT, lexer.ch)
+      }
+    case '%':
+      tok = newToken(token.PERCENT, lexer.ch)
+    case '&':
+      t, didCreate := lexer.makeTwoCharToken('&', token.AND)
+      if didCreate {
+        tok = t
+      } else {
+        tok = newToken(token.ILLEGAL, lexer.ch)
+      }
+    case '|':
+      t, didCreate := lexer.makeTwoCharToken('|', token.OR)
+      if didCreate {
+        tok = t
+      } else {
+        tok = newToken(token.ILLEGAL, lexer.ch)
+      }
     case '-':
-      tok = newToken(token.MINUS, lexer.ch)
+      t, didCreate := lexer.makeTwoCharToken('=', token.MINUS_ASSIGN)
+      if didCreate {
+        tok = t
+      } else {
+        tok = newToken(token.MINUS, lexer.ch)
+      }
     case 0:
       tok.Literal = ""
       tok.Type = token.EOF
     case '"':
-      tok.Type = token.STRING
-      tok.Literal = lexer.readString()
+      tok = lexer.readString(pos)
+    case '`':
+      tok = lexer.readRawString(pos)
     case '[':
       tok = newToken(token.LBRACKET, lexer.ch)
     case ']':
       tok = newToken(token.RBRACKET, lexer.ch)
     case ':':
       tok = newToken(token.COLON, lexer.ch)
+    case '.':
+      if lexer.peekChar() == '.' && lexer.readPosition+1 < len(lexer.input) && lexer.input[lexer.readPosition+1] == '.' {
+        lexer.readChar()
+        lexer.readChar()
+        tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+      } else {
+        lexer.addError(pos, "unexpected character %q", lexer.ch)
+        tok = newToken(token.ILLEGAL, lexer.ch)
+      }
     default:
       if isLetter(lexer.ch) {
         tok.Literal = lexer.readIdentifier()
         tok.Type = token.LookupIdent(tok.Literal)
+        tok.Pos = pos
+        tok.Leading = comments
         return tok // readIdentifier calls readChar repeatedly to update positions, so we early return here.
       } else if isDigit(lexer.ch) {
-        tok.Type = token.INT
-        tok.Literal = lexer.readNumber()
+        tok.Literal, tok.Type = lexer.readNumber()
+        tok.Pos = pos
+        tok.Leading = comments
         return tok
       } else {
+        lexer.addError(pos, "unexpected character %q", lexer.ch)
         tok = newToken(token.ILLEGAL, lexer.ch)
       }
   }
 
   lexer.readChar()
+  tok.Pos = pos
+  tok.Leading = comments
   return tok
 }
 
+// currentPosition is the position of the character the lexer is looking at.
+func (lexer *Lexer) currentPosition() token.Position {
+  return token.Position{
+    Filename: lexer.filename,
+    Offset: lexer.position,
+    Line: lexer.line,
+    Column: lexer.position - lexer.lineStart + 1,
+  }
+}
+
 func (lexer *Lexer) readIdentifier() string {
   position := lexer.position
   for isLetter(lexer.ch) {
@@ -124,23 +219,209 @@ func (lexer *Lexer) skipWhitespace() {
   }
 }
 
-func (lexer *Lexer) readNumber() string {
+// skipTrivia skips whitespace and comments up to the next token and returns the comments. It reports
+// false for a block comment that is never closed, that comment is the last one returned.
+func (lexer *Lexer) skipTrivia() ([]token.Comment, bool) {
+  var comments []token.Comment
+
+  for {
+    lexer.skipWhitespace()
+
+    if lexer.ch != '/' || (lexer.peekChar() != '/' && lexer.peekChar() != '*') {
+      return comments, true
+    }
+
+    pos := lexer.currentPosition()
+    closed := true
+
+    if lexer.peekChar() == '/' {
+      for lexer.ch != '\n' && lexer.ch != 0 {
+        lexer.readChar()
+      }
+    } else {
+      lexer.readChar()
+      lexer.readChar()
+      for !(lexer.ch == '*' && lexer.peekChar() == '/') && lexer.ch != 0 {
+        lexer.readChar()
+      }
+
+      if lexer.ch == 0 {
+        lexer.addError(pos, "unterminated comment")
+        closed = false
+      } else {
+        lexer.readChar()
+        lexer.readChar()
+      }
+    }
+
+    comments = append(comments, token.Comment{Pos: pos, Text: lexer.input[pos.Offset:lexer.position]})
+    if !closed {
+      return comments, false
+    }
+  }
+}
+
+// readNumber reads an integer, or a float when the digits go on with a fraction, an exponent or both,
+// as in 1.5, 1e9 and 2.5E-3. A dot or an e that isn't followed by a digit is left for the next token.
+func (lexer *Lexer) readNumber() (string, token.TokenType) {
   position := lexer.position
+  tokenType := token.TokenType(token.INT)
+
+  lexer.readDigits()
+
+  if lexer.ch == '.' && isDigit(lexer.peekChar()) {
+    tokenType = token.FLOAT
+    lexer.readChar()
+    lexer.readDigits()
+  }
+
+  if lexer.ch == 'e' || lexer.ch == 'E' {
+    exponent := lexer.readPosition
+    if exponent < len(lexer.input) && (lexer.input[exponent] == '+' || lexer.input[exponent] == '-') {
+      exponent++
+    }
+
+    if exponent < len(lexer.input) && isDigit(lexer.input[exponent]) {
+      tokenType = token.FLOAT
+      for lexer.readPosition < exponent {
+        lexer.readChar()
+      }
+      lexer.readChar()
+      lexer.readDigits()
+    }
+  }
+
+  return lexer.input[position:lexer.position], tokenType
+}
+
+func (lexer *Lexer) readDigits() {
   for isDigit(lexer.ch) {
     lexer.readChar()
   }
-  return lexer.input[position:lexer.position]
 }
 
-func (lexer *Lexer) readString() string {
+// readString reads a double-quoted string, resolving its escape sequences. The string has to end on the
+// line it starts on, raw strings are there for longer text. A string that isn't closed or holds an
+// invalid escape becomes an ILLEGAL token.
+func (lexer *Lexer) readString(start token.Position) token.Token {
+  var out strings.Builder
+  valid := true
+
+  for {
+    lexer.readChar()
+
+    switch lexer.ch {
+    case '"':
+      if !valid {
+        return token.Token{Type: token.ILLEGAL, Literal: lexer.input[start.Offset:lexer.position+1]}
+      }
+      return token.Token{Type: token.STRING, Literal: out.String()}
+
+    case 0, '\n':
+      lexer.addError(start, "unterminated string")
+      return token.Token{Type: token.ILLEGAL, Literal: lexer.input[start.Offset:lexer.position]}
+
+    case '\\':
+      if lexer.peekChar() == 0 || lexer.peekChar() == '\n' {
+        continue // reported as unterminated on the next turn
+      }
+
+      escape := lexer.currentPosition()
+      lexer.readChar()
+      if !lexer.readEscape(&out) {
+        lexer.addError(escape, "invalid escape sequence %s", lexer.input[escape.Offset:lexer.position+1])
+        valid = false
+      }
+
+    default:
+      out.WriteByte(lexer.ch)
+    }
+  }
+}
+
+// readEscape writes the character an escape sequence stands for, lexer.ch is the character after the
+// backslash. It reports false for an unknown sequence or one that isn't a valid code point.
+func (lexer *Lexer) readEscape(out *strings.Builder) bool {
+  switch lexer.ch {
+  case 'n':
+    out.WriteByte('\n')
+  case 't':
+    out.WriteByte('\t')
+  case 'r':
+    out.WriteByte('\r')
+  case '0':
+    out.WriteByte(0)
+  case '\\', '"':
+    out.WriteByte(lexer.ch)
+  case 'x':
+    value, ok := lexer.readHex(2)
+    if !ok {
+      return false
+    }
+    out.WriteByte(byte(value))
+  case 'u', 'U':
+    digits := 4
+    if lexer.ch == 'U' {
+      digits = 8
+    }
+
+    value, ok := lexer.readHex(digits)
+    if !ok || !utf8.ValidRune(rune(value)) {
+      return false
+    }
+    out.WriteRune(rune(value))
+  default:
+    return false
+  }
+
+  return true
+}
+
+// readHex reads exactly n hex digits following lexer.ch. It stops before the first character that isn't one.
+func (lexer *Lexer) readHex(n int) (uint32, bool) {
+  var value uint32
+
+  for i := 0; i < n; i++ {
+    digit, ok := hexValue(lexer.peekChar())
+    if !ok {
+      return 0, false
+    }
+
+    lexer.readChar()
+    value = value*16 + digit
+  }
+
+  return value, true
+}
+
+func hexValue(ch byte) (uint32, bool) {
+  switch {
+  case '0' <= ch && ch <= '9':
+    return uint32(ch - '0'), true
+  case 'a' <= ch && ch <= 'f':
+    return uint32(ch - 'a' + 10), true
+  case 'A' <= ch && ch <= 'F':
+    return uint32(ch - 'A' + 10), true
+  default:
+    return 0, false
+  }
+}
+
+// readRawString reads a string between backticks. Nothing is escaped in it and it may span lines.
+func (lexer *Lexer) readRawString(start token.Position) token.Token {
   position := lexer.position + 1
+
   for {
     lexer.readChar()
-    if lexer.ch == '"' || lexer.ch == 0 {
-      break
+
+    switch lexer.ch {
+    case '`':
+      return token.Token{Type: token.STRING, Literal: lexer.input[position:lexer.position]}
+    case 0:
+      lexer.addError(start, "unterminated raw string")
+      return token.Token{Type: token.ILLEGAL, Literal: lexer.input[start.Offset:lexer.position]}
     }
   }
-  return lexer.input[position:lexer.position]
 }
 
 func isDigit(ch byte) bool {
@@ -15,7 +15,7 @@ func TestNextToken(t *testing.T) {
             };
 
             let result = add(five, ten);
-            !-/*5;
+            !-/ *5; // "/*" would start a block comment
             5 < 10 > 5;
 
             if (5 < 10) {
@@ -30,6 +30,11 @@ func TestNextToken(t *testing.T) {
 						"foo bar"
 						[1, 2];
 						{"foo": "bar"}
+						macro(x, y) { x + y; };
+						while for in break continue
+						x += 1 -= *= /= =
+						a <= b >= c % d && e || f < >
+						...rest
 `
 
   tests := []struct {
@@ -122,6 +127,46 @@ func TestNextToken(t *testing.T) {
 		{token.COLON, ":"},
 		{token.STRING, "bar"},
 		{token.RBRACE, "}"},
+		{token.MACRO, "macro"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.COMMA, ","},
+		{token.IDENT, "y"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.PLUS, "+"},
+		{token.IDENT, "y"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+		{token.WHILE, "while"},
+		{token.FOR, "for"},
+		{token.IN, "in"},
+		{token.BREAK, "break"},
+		{token.CONTINUE, "continue"},
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.SLASH_ASSIGN, "/="},
+		{token.ASSIGN, "="},
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "d"},
+		{token.AND, "&&"},
+		{token.IDENT, "e"},
+		{token.OR, "||"},
+		{token.IDENT, "f"},
+		{token.LT, "<"},
+		{token.GT, ">"},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest"},
 		{token.EOF, ""},
 	}
 
@@ -140,3 +185,167 @@ func TestNextToken(t *testing.T) {
 
 }
 
+func TestTokenPositions(t *testing.T) {
+  input := "let x = 5;\n  x == \"ab\";\n"
+
+  tests := []struct {
+    expectedType   token.TokenType
+    expectedOffset int
+    expectedLine   int
+    expectedColumn int
+  }{
+    {token.LET, 0, 1, 1},
+    {token.IDENT, 4, 1, 5},
+    {token.ASSIGN, 6, 1, 7},
+    {token.INT, 8, 1, 9},
+    {token.SEMICOLON, 9, 1, 10},
+    {token.IDENT, 13, 2, 3},
+    {token.EQ, 15, 2, 5},
+    {token.STRING, 18, 2, 8},
+    {token.SEMICOLON, 22, 2, 12},
+    {token.EOF, 24, 3, 1},
+  }
+
+  l := NewFile("test.mk", input)
+
+  for i, tt := range tests {
+    tok := l.NextToken()
+    if tok.Type != tt.expectedType {
+      t.Fatalf("tests[%d] - Token type incorrect. Expected=%q, received=%q", i, tt.expectedType, tok.Type)
+    }
+
+    if tok.Pos.Filename != "test.mk" {
+      t.Fatalf("tests[%d] - Filename incorrect. Expected=%q, received=%q", i, "test.mk", tok.Pos.Filename)
+    }
+
+    if tok.Pos.Offset != tt.expectedOffset || tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+      t.Fatalf("tests[%d] - Position incorrect. Expected=%d:%d (offset %d), received=%d:%d (offset %d)",
+        i, tt.expectedLine, tt.expectedColumn, tt.expectedOffset, tok.Pos.Line, tok.Pos.Column, tok.Pos.Offset)
+    }
+  }
+}
+
+func TestNumbers(t *testing.T) {
+  tests := []struct {
+    input    string
+    expected []token.Token
+  }{
+    {"42", []token.Token{{Type: token.INT, Literal: "42"}}},
+    {"3.14", []token.Token{{Type: token.FLOAT, Literal: "3.14"}}},
+    {"1e9", []token.Token{{Type: token.FLOAT, Literal: "1e9"}}},
+    {"6.02E+23", []token.Token{{Type: token.FLOAT, Literal: "6.02E+23"}}},
+    {"2.5e-3", []token.Token{{Type: token.FLOAT, Literal: "2.5e-3"}}},
+    {"1.", []token.Token{{Type: token.INT, Literal: "1"}, {Type: token.ILLEGAL, Literal: "."}}},
+    {"2e", []token.Token{{Type: token.INT, Literal: "2"}, {Type: token.IDENT, Literal: "e"}}},
+    {"3e+x", []token.Token{{Type: token.INT, Literal: "3"}, {Type: token.IDENT, Literal: "e"}, {Type: token.PLUS, Literal: "+"}, {Type: token.IDENT, Literal: "x"}}},
+  }
+
+  for _, tt := range tests {
+    l := New(tt.input)
+
+    for i, expected := range tt.expected {
+      tok := l.NextToken()
+      if tok.Type != expected.Type || tok.Literal != expected.Literal {
+        t.Errorf("%q tokens[%d] wrong. expected=%s %q, received=%s %q", tt.input, i, expected.Type, expected.Literal, tok.Type, tok.Literal)
+      }
+    }
+
+    if tok := l.NextToken(); tok.Type != token.EOF {
+      t.Errorf("%q expected EOF, received=%s %q", tt.input, tok.Type, tok.Literal)
+    }
+  }
+}
+
+func TestStrings(t *testing.T) {
+  tests := []struct {
+    input           string
+    expectedType    token.TokenType
+    expectedLiteral string
+    expectedError   string
+  }{
+    {`"plain"`, token.STRING, "plain", ""},
+    {`"a\nb\tc\r\\d"`, token.STRING, "a\nb\tc\r\\d", ""},
+    {`"say \"hi\""`, token.STRING, `say "hi"`, ""},
+    {`"café \U0001F600 \x41\0"`, token.STRING, "café 😀 A\x00", ""},
+    {"\"é\"", token.STRING, "é", ""},
+    {"`raw \\n \"quoted\"\nsecond line`", token.STRING, "raw \\n \"quoted\"\nsecond line", ""},
+    {"``", token.STRING, "", ""},
+    {`"no end`, token.ILLEGAL, `"no end`, "1:1: unterminated string"},
+    {"\"no end\n\"", token.ILLEGAL, `"no end`, "1:1: unterminated string"},
+    {`"trailing \`, token.ILLEGAL, `"trailing \`, "1:1: unterminated string"},
+    {"`no end", token.ILLEGAL, "`no end", "1:1: unterminated raw string"},
+    {`"bad \q"`, token.ILLEGAL, `"bad \q"`, `1:6: invalid escape sequence \q`},
+    {`"\u12"`, token.ILLEGAL, `"\u12"`, `1:2: invalid escape sequence \u12`},
+    {`"\uD800"`, token.ILLEGAL, `"\uD800"`, `1:2: invalid escape sequence \uD800`},
+    {"#", token.ILLEGAL, "#", "1:1: unexpected character '#'"},
+  }
+
+  for _, tt := range tests {
+    l := New(tt.input)
+    tok := l.NextToken()
+
+    if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+      t.Errorf("%s: wrong token. expected=%s %q, received=%s %q", tt.input, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+    }
+
+    errors := l.Errors()
+    if tt.expectedError == "" {
+      if len(errors) != 0 {
+        t.Errorf("%s: unexpected errors %v", tt.input, errors)
+      }
+      continue
+    }
+
+    if len(errors) == 0 || errors[0].Error() != tt.expectedError {
+      t.Errorf("%s: wrong errors. expected=%q, received=%v", tt.input, tt.expectedError, errors)
+    }
+  }
+}
+
+func TestComments(t *testing.T) {
+  input := `// leading
+let x = 5; // trailing
+/* block
+   comment */ x /* inline */ + /**/ 1
+/* unterminated`
+
+  tests := []struct {
+    expectedType    token.TokenType
+    expectedLiteral string
+    expectedLeading []string
+  }{
+    {token.LET, "let", []string{"// leading"}},
+    {token.IDENT, "x", nil},
+    {token.ASSIGN, "=", nil},
+    {token.INT, "5", nil},
+    {token.SEMICOLON, ";", nil},
+    {token.IDENT, "x", []string{"// trailing", "/* block\n   comment */"}},
+    {token.PLUS, "+", []string{"/* inline */"}},
+    {token.INT, "1", []string{"/**/"}},
+    {token.ILLEGAL, "/* unterminated", nil},
+    {token.EOF, "", nil},
+  }
+
+  l := New(input)
+
+  for i, tt := range tests {
+    tok := l.NextToken()
+    if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+      t.Fatalf("tests[%d] - wrong token. expected=%s %q, received=%s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+    }
+
+    if len(tok.Leading) != len(tt.expectedLeading) {
+      t.Fatalf("tests[%d] - wrong number of comments. expected=%d, received=%d", i, len(tt.expectedLeading), len(tok.Leading))
+    }
+
+    for j, text := range tt.expectedLeading {
+      if tok.Leading[j].Text != text {
+        t.Errorf("tests[%d] - wrong comment. expected=%q, received=%q", i, text, tok.Leading[j].Text)
+      }
+    }
+  }
+
+  if len(l.Errors()) != 1 || l.Errors()[0].Error() != "5:1: unterminated comment" {
+    t.Errorf("wrong errors. got=%v", l.Errors())
+  }
+}
@@ -1,21 +1,180 @@
 package main
 
 import (
+  "flag"
   "fmt"
+  "io"
   "os"
   "os/user"
+  "path/filepath"
+  "monkey/ast"
+  "monkey/compiler"
+  "monkey/evaluator"
+  "monkey/lexer"
+  "monkey/object"
+  "monkey/parser"
   "monkey/repl"
+  "monkey/token"
+  "monkey/vm"
+)
+
+const USAGE = `Usage: monkey <command> [flags] [arguments]
+
+Commands:
+  run <file>   parse and evaluate a Monkey source file
+  repl         start the interactive REPL (the default when no command is given)
+  help         show this message
+
+Flags for run and repl:
+  -engine string
+               execution engine, "eval" (tree-walking evaluator) or "vm" (bytecode compiler) (default "eval")
+  -path string
+               directories to search for imported files, separated like PATH (default $MONKEYPATH)
+`
+
+// Exit codes returned by the monkey command.
+const (
+  EXIT_OK    = 0
+  EXIT_ERROR = 1 // the program failed to parse or evaluated to an error
+  EXIT_USAGE = 2 // the command line itself was wrong
 )
 
 func main() {
+  os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+  if len(args) == 0 {
+    return startRepl(repl.ENGINE_EVAL, filepath.SplitList(os.Getenv("MONKEYPATH")), stdin, stdout, stderr)
+  }
+
+  switch args[0] {
+  case "repl", "run":
+    command := args[0]
+    flags := flag.NewFlagSet(command, flag.ContinueOnError)
+    flags.SetOutput(io.Discard)
+    engine := flags.String("engine", repl.ENGINE_EVAL, "execution engine")
+    path := flags.String("path", os.Getenv("MONKEYPATH"), "module search paths")
+
+    if err := flags.Parse(args[1:]); err != nil {
+      return usageError(stderr, err.Error())
+    }
+
+    if *engine != repl.ENGINE_EVAL && *engine != repl.ENGINE_VM {
+      return usageError(stderr, fmt.Sprintf("unknown engine %q", *engine))
+    }
+
+    if command == "repl" {
+      if flags.NArg() != 0 {
+        return usageError(stderr, "repl does not take any arguments")
+      }
+      return startRepl(*engine, filepath.SplitList(*path), stdin, stdout, stderr)
+    }
+
+    if flags.NArg() != 1 {
+      return usageError(stderr, fmt.Sprintf("run expects exactly one file, got %d arguments", flags.NArg()))
+    }
+    return runFile(flags.Arg(0), *engine, filepath.SplitList(*path), stderr)
+  case "help", "-h", "--help":
+    io.WriteString(stdout, USAGE)
+    return EXIT_OK
+  default:
+    return usageError(stderr, fmt.Sprintf("unknown command %q", args[0]))
+  }
+}
+
+func usageError(stderr io.Writer, msg string) int {
+  fmt.Fprintf(stderr, "%s\n\n", msg)
+  io.WriteString(stderr, USAGE)
+  return EXIT_USAGE
+}
+
+func startRepl(engine string, searchPaths []string, stdin io.Reader, stdout, stderr io.Writer) int {
   user, err := user.Current()
-  
+
   if err != nil {
-    panic(err)
+    fmt.Fprintf(stderr, "could not look up the current user: %s\n", err)
+    return EXIT_ERROR
   }
 
-  fmt.Printf("Hello %s! This is the Monkey Programming Language!\n", user.Username)
+  fmt.Fprintf(stdout, "Hello %s! This is the Monkey Programming Language!\n", user.Username)
+
+  fmt.Fprintf(stdout, "Type in some commands \n")
+  repl.Start(stdin, stdout, engine, searchPaths)
+  return EXIT_OK
+}
+
+// runFile parses the whole file up front, so a syntax error anywhere stops the program before any of it runs.
+func runFile(path string, engine string, searchPaths []string, stderr io.Writer) int {
+  source, err := os.ReadFile(path)
+
+  if err != nil {
+    fmt.Fprintf(stderr, "%s\n", err)
+    return EXIT_ERROR
+  }
+
+  l := lexer.NewFile(path, string(source))
+  p := parser.New(l)
+  program := p.ParseProgram()
+
+  if len(p.Errors()) != 0 {
+    for _, msg := range p.Errors() {
+      fmt.Fprintf(stderr, "%s\n", msg)
+    }
+    return EXIT_ERROR
+  }
+
+  macroEnv := object.NewEnvironment()
+  evaluator.DefineMacros(program, macroEnv)
+  expanded, errObj := evaluator.ExpandMacros(program, macroEnv)
+
+  if errObj != nil {
+    fmt.Fprintf(stderr, "%s: %s\n", errorLocation(path, errObj.Pos), errObj.Message)
+    return EXIT_ERROR
+  }
+
+  program = expanded.(*ast.Program)
+
+  if engine == repl.ENGINE_VM {
+    return runCompiled(path, program, stderr)
+  }
+
+  env := object.NewEnvironment()
+  env.SetModules(object.NewModules(searchPaths...))
+  evaluated := evaluator.Eval(program, env)
+
+  if errObj, ok := evaluated.(*object.Error); ok {
+    io.WriteString(stderr, errObj.Traceback())
+    fmt.Fprintf(stderr, "%s: %s\n", errorLocation(path, errObj.Pos), errObj.Message)
+    return EXIT_ERROR
+  }
+
+  return EXIT_OK
+}
+
+func runCompiled(path string, program *ast.Program, stderr io.Writer) int {
+  comp := compiler.New()
+
+  if err := comp.Compile(program); err != nil {
+    fmt.Fprintf(stderr, "%s\n", err)
+    return EXIT_ERROR
+  }
+
+  machine := vm.New(comp.Bytecode())
+
+  if err := machine.Run(); err != nil {
+    fmt.Fprintf(stderr, "%s\n", err)
+    return EXIT_ERROR
+  }
+
+  return EXIT_OK
+}
+
+// errorLocation falls back to the file name when the error has no position, e.g. one built by hand in a builtin.
+func errorLocation(path string, pos token.Position) string {
+  if pos.IsValid() {
+    return pos.String()
+  }
 
-  fmt.Printf("Type in some commands \n")
-  repl.Start(os.Stdin, os.Stdout)
+  return path
 }
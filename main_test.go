@@ -0,0 +1,101 @@
+package main
+
+import (
+  "bytes"
+  "io"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+func TestRun(t *testing.T) {
+  dir := t.TempDir()
+  files := map[string]string{
+    "ok.mk": "let add = fn(a, b) { a + b };\nputs(add(1, 2));\nputs(\"done\");\n",
+    "syntax.mk": "let x = 1;\nlet = 2;\n",
+    "runtime.mk": "puts(\"before\");\nlet x = 1 + true;\nputs(\"after\");\n",
+    "builtin.mk": "let x = len(1);\nputs(\"after\");\n",
+  }
+  for name, source := range files {
+    if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0644); err != nil {
+      t.Fatal(err)
+    }
+  }
+  path := func(name string) string { return filepath.Join(dir, name) }
+
+  tests := []struct {
+    args   []string
+    code   int
+    stdout string
+    stderr string // a substring of what is written to stderr, empty when nothing should be
+  }{
+    {[]string{"run", path("ok.mk")}, EXIT_OK, "3\ndone\n", ""},
+    {[]string{"run", "-engine=vm", path("ok.mk")}, EXIT_OK, "3\ndone\n", ""},
+    {[]string{"run", path("syntax.mk")}, EXIT_ERROR, "", path("syntax.mk") + ":2:5: "},
+    {[]string{"run", "-engine=vm", path("syntax.mk")}, EXIT_ERROR, "", path("syntax.mk") + ":2:5: "},
+    {[]string{"run", path("runtime.mk")}, EXIT_ERROR, "before\n", path("runtime.mk") + ":2:11: type mismatch: INTEGER + BOOLEAN"},
+    {[]string{"run", "-engine=vm", path("runtime.mk")}, EXIT_ERROR, "before\n", path("runtime.mk") + ":2:11: type mismatch: INTEGER + BOOLEAN"},
+    {[]string{"run", path("builtin.mk")}, EXIT_ERROR, "", path("builtin.mk") + ":1:12: argument to `len` not supported, got INTEGER"},
+    {[]string{"run", "-engine=vm", path("builtin.mk")}, EXIT_ERROR, "", path("builtin.mk") + ":1:12: argument to `len` not supported, got INTEGER"},
+    {[]string{"run", path("missing.mk")}, EXIT_ERROR, "", "no such file or directory"},
+    {[]string{"help"}, EXIT_OK, USAGE, ""},
+    {[]string{"frobnicate"}, EXIT_USAGE, "", `unknown command "frobnicate"`},
+    {[]string{"run"}, EXIT_USAGE, "", "run expects exactly one file, got 0 arguments"},
+    {[]string{"run", path("ok.mk"), path("ok.mk")}, EXIT_USAGE, "", "run expects exactly one file, got 2 arguments"},
+    {[]string{"repl", "extra"}, EXIT_USAGE, "", "repl does not take any arguments"},
+    {[]string{"run", "-engine=jit", path("ok.mk")}, EXIT_USAGE, "", `unknown engine "jit"`},
+    {[]string{"run", "-nope", path("ok.mk")}, EXIT_USAGE, "", "flag provided but not defined: -nope"},
+  }
+
+  for _, tt := range tests {
+    var stderr bytes.Buffer
+    var code int
+    stdout := captureStdout(t, func(stdout io.Writer) {
+      code = run(tt.args, strings.NewReader(""), stdout, &stderr)
+    })
+
+    if code != tt.code {
+      t.Errorf("%v: wrong exit code. want=%d, got=%d (stderr %q)", tt.args, tt.code, code, stderr.String())
+    }
+
+    if stdout != tt.stdout {
+      t.Errorf("%v: wrong stdout. want=%q, got=%q", tt.args, tt.stdout, stdout)
+    }
+
+    if tt.stderr == "" && stderr.Len() != 0 {
+      t.Errorf("%v: unexpected stderr %q", tt.args, stderr.String())
+    }
+    if !strings.Contains(stderr.String(), tt.stderr) {
+      t.Errorf("%v: stderr doesn't contain %q. got=%q", tt.args, tt.stderr, stderr.String())
+    }
+
+    if tt.code == EXIT_USAGE && !strings.HasSuffix(stderr.String(), USAGE) {
+      t.Errorf("%v: usage error without the usage message. got=%q", tt.args, stderr.String())
+    }
+  }
+}
+
+// captureStdout runs f with a writer for stdout and returns what was written to it, including what
+// puts wrote straight to os.Stdout.
+func captureStdout(t *testing.T, f func(stdout io.Writer)) string {
+  t.Helper()
+
+  file, err := os.CreateTemp(t.TempDir(), "stdout")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer file.Close()
+
+  saved := os.Stdout
+  os.Stdout = file
+  defer func() { os.Stdout = saved }()
+
+  f(file)
+
+  out, err := os.ReadFile(file.Name())
+  if err != nil {
+    t.Fatal(err)
+  }
+  return string(out)
+}
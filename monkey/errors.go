@@ -0,0 +1,34 @@
+package monkey
+
+import (
+  "monkey/object"
+  "monkey/parser"
+  "strings"
+)
+
+// SyntaxError holds every error the parser found in a script.
+type SyntaxError struct {
+  Errors []*parser.ParseError
+}
+
+func (e *SyntaxError) Error() string {
+  msgs := []string{}
+  for _, err := range e.Errors {
+    msgs = append(msgs, err.Error())
+  }
+
+  return strings.Join(msgs, "\n")
+}
+
+// RuntimeError is a script that failed while running, Err carries the position and call stack.
+type RuntimeError struct {
+  Err *object.Error
+}
+
+func (e *RuntimeError) Error() string {
+  if e.Err.Pos.IsValid() {
+    return e.Err.Pos.String() + ": " + e.Err.Message
+  }
+
+  return e.Err.Message
+}
@@ -0,0 +1,143 @@
+// Package monkey embeds the Monkey interpreter in Go programs.
+//
+//   interp := monkey.New()
+//   interp.SetGlobal("limit", &object.Integer{Value: 10})
+//   result, err := interp.Eval(`limit * 2`)
+//
+// Each Interpreter keeps its own globals and macros between calls to Eval, and is not safe for
+// concurrent use.
+package monkey
+
+import (
+  "context"
+  "fmt"
+  "monkey/ast"
+  "monkey/evaluator"
+  "monkey/lexer"
+  "monkey/object"
+  "monkey/parser"
+)
+
+type Interpreter struct {
+  env      *object.Environment
+  macroEnv *object.Environment
+
+  // Limits bounds every Eval and Call, a script exceeding them fails with a *RuntimeError.
+  Limits evaluator.Limits
+}
+
+func New() *Interpreter {
+  interp := &Interpreter{
+    env:      object.NewEnvironment(),
+    macroEnv: object.NewEnvironment(),
+  }
+  interp.env.SetModules(object.NewModules())
+
+  return interp
+}
+
+// Eval runs src in the interpreter's global environment and returns the value of its last statement,
+// null if that statement doesn't produce one. Errors are a *SyntaxError when src doesn't parse and a
+// *RuntimeError when it fails while running.
+func (interp *Interpreter) Eval(src string) (object.Object, error) {
+  return interp.EvalContext(context.Background(), src)
+}
+
+// EvalContext is Eval, stopping with a *RuntimeError once ctx is done.
+func (interp *Interpreter) EvalContext(ctx context.Context, src string) (object.Object, error) {
+  p := parser.New(lexer.New(src))
+  program := p.ParseProgram()
+
+  if len(p.Errors()) != 0 {
+    return nil, &SyntaxError{Errors: p.Errors()}
+  }
+
+  defer interp.setBudget(ctx)()
+
+  evaluator.DefineMacros(program, interp.macroEnv)
+  expanded, errObj := evaluator.ExpandMacros(program, interp.macroEnv)
+  if errObj != nil {
+    return nil, &RuntimeError{Err: errObj}
+  }
+
+  return result(evaluator.Eval(expanded.(*ast.Program), interp.env))
+}
+
+// SetGlobal binds name to value, as if the script had run `let name = value`.
+func (interp *Interpreter) SetGlobal(name string, value object.Object) {
+  interp.env.Set(name, value)
+}
+
+// GetGlobal returns the value bound to name, reporting false if there is none.
+func (interp *Interpreter) GetGlobal(name string) (object.Object, bool) {
+  return interp.env.Get(name)
+}
+
+// SetSearchPaths sets the directories scripts import files from, after the working directory. Files
+// already imported stay cached.
+func (interp *Interpreter) SetSearchPaths(paths ...string) {
+  interp.env.Modules().SearchPaths = paths
+}
+
+// RegisterBuiltin makes fn callable from scripts as name. It shadows a standard builtin of the same name
+// for this interpreter only.
+func (interp *Interpreter) RegisterBuiltin(name string, fn object.BuiltinFunction) {
+  interp.env.Set(name, &object.Builtin{Fn: fn})
+}
+
+// Call calls the function bound to fnName with args and returns its result. Like in a script, the
+// standard builtins can be called by name unless a global shadows them.
+func (interp *Interpreter) Call(fnName string, args ...object.Object) (object.Object, error) {
+  return interp.CallContext(context.Background(), fnName, args...)
+}
+
+// CallContext is Call, stopping with a *RuntimeError once ctx is done.
+func (interp *Interpreter) CallContext(ctx context.Context, fnName string, args ...object.Object) (object.Object, error) {
+  fn, ok := interp.env.Get(fnName)
+  if !ok {
+    if builtin := object.GetBuiltinByName(fnName); builtin != nil {
+      fn = builtin
+    } else {
+      return nil, fmt.Errorf("identifier not found: %s", fnName)
+    }
+  }
+
+  switch fn := fn.(type) {
+  case *object.Function:
+    if err := fn.ArityError(len(args)); err != nil {
+      return nil, fmt.Errorf("%s: %s", fnName, err.Message)
+    }
+  case *object.Builtin:
+  default:
+    return nil, fmt.Errorf("not a function: %s", fn.Type())
+  }
+
+  defer interp.setBudget(ctx)()
+
+  return result(evaluator.ApplyFunction(fn, args))
+}
+
+// setBudget gives the globals and macros a fresh budget built from ctx and Limits, and returns a func
+// that removes it again.
+func (interp *Interpreter) setBudget(ctx context.Context) func() {
+  budget := object.NewBudget(ctx, interp.Limits.MaxSteps, interp.Limits.MaxCallDepth)
+  interp.env.SetBudget(budget)
+  interp.macroEnv.SetBudget(budget)
+
+  return func() {
+    interp.env.SetBudget(nil)
+    interp.macroEnv.SetBudget(nil)
+  }
+}
+
+func result(obj object.Object) (object.Object, error) {
+  if errObj, ok := obj.(*object.Error); ok {
+    return nil, &RuntimeError{Err: errObj}
+  }
+
+  if obj == nil {
+    return evaluator.NULL, nil
+  }
+
+  return obj, nil
+}
@@ -0,0 +1,244 @@
+package monkey
+
+import (
+  "context"
+  "errors"
+  "monkey/object"
+  "os"
+  "path/filepath"
+  "testing"
+)
+
+func TestEval(t *testing.T) {
+  interp := New()
+
+  result, err := interp.Eval(`let double = fn(x) { x * 2 }; double(21)`)
+  if err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  testInteger(t, result, 42)
+
+  // globals and macros survive between calls
+  if _, err := interp.Eval(`let twice = macro(e) { quote(unquote(e) + unquote(e)) };`); err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  result, err = interp.Eval(`twice(double(1))`)
+  if err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  testInteger(t, result, 4)
+}
+
+func TestEvalLetReturnsNull(t *testing.T) {
+  result, err := New().Eval(`let x = 1;`)
+  if err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  if result.Type() != object.NULL_OBJ {
+    t.Errorf("expected null. got=%s", result.Inspect())
+  }
+}
+
+func TestEvalErrors(t *testing.T) {
+  interp := New()
+
+  _, err := interp.Eval(`let = 5;`)
+  var syntaxErr *SyntaxError
+  if !errors.As(err, &syntaxErr) {
+    t.Fatalf("expected a *SyntaxError. got=%T (%v)", err, err)
+  }
+
+  if len(syntaxErr.Errors) != 1 {
+    t.Errorf("wrong number of parse errors. got=%d", len(syntaxErr.Errors))
+  }
+
+  _, err = interp.Eval("let f = fn() { 1 + true };\nf();")
+  var runtimeErr *RuntimeError
+  if !errors.As(err, &runtimeErr) {
+    t.Fatalf("expected a *RuntimeError. got=%T (%v)", err, err)
+  }
+
+  expected := "1:18: type mismatch: INTEGER + BOOLEAN"
+  if err.Error() != expected {
+    t.Errorf("wrong error message. want=%q, got=%q", expected, err.Error())
+  }
+
+  if len(runtimeErr.Err.Stack) != 1 {
+    t.Errorf("expected the error to carry its call stack. got=%d frames", len(runtimeErr.Err.Stack))
+  }
+}
+
+func TestGlobals(t *testing.T) {
+  interp := New()
+  interp.SetGlobal("limit", &object.Integer{Value: 10})
+
+  if _, err := interp.Eval(`let over = limit + 1;`); err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  over, ok := interp.GetGlobal("over")
+  if !ok {
+    t.Fatalf("global over not defined")
+  }
+
+  testInteger(t, over, 11)
+
+  if _, ok := interp.GetGlobal("missing"); ok {
+    t.Errorf("expected missing to be undefined")
+  }
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+  interp := New()
+
+  calls := 0
+  interp.RegisterBuiltin("record", func(args ...object.Object) object.Object {
+    calls++
+    return &object.Integer{Value: int64(len(args))}
+  })
+
+  result, err := interp.Eval(`record(1, 2, 3)`)
+  if err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  testInteger(t, result, 3)
+
+  if calls != 1 {
+    t.Errorf("builtin called %d times, want 1", calls)
+  }
+
+  // the registration is local to the interpreter
+  if _, err := New().Eval(`record()`); err == nil {
+    t.Errorf("expected record to be undefined in a new interpreter")
+  }
+}
+
+func TestCall(t *testing.T) {
+  interp := New()
+
+  if _, err := interp.Eval(`let add = fn(a, b) { a + b }; let inc = fn(a, by = 1) { a + by }; let n = 1;`); err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  result, err := interp.Call("add", &object.Integer{Value: 2}, &object.Integer{Value: 3})
+  if err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  testInteger(t, result, 5)
+
+  result, err = interp.Call("len", &object.String{Value: "four"})
+  if err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  testInteger(t, result, 4)
+
+  result, err = interp.Call("inc", &object.Integer{Value: 41})
+  if err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  testInteger(t, result, 42)
+
+  tests := []struct {
+    fnName   string
+    args     []object.Object
+    expected string
+  }{
+    {"missing", nil, "identifier not found: missing"},
+    {"n", nil, "not a function: INTEGER"},
+    {"add", []object.Object{&object.Integer{Value: 1}}, "add: wrong number of arguments: want=2, got=1"},
+    {"inc", nil, "inc: wrong number of arguments: want=1 to 2, got=0"},
+    {"add", []object.Object{&object.Integer{Value: 1}, &object.String{Value: "a"}}, "1:24: type mismatch: INTEGER + STRING"},
+  }
+
+  for _, tt := range tests {
+    _, err := interp.Call(tt.fnName, tt.args...)
+    if err == nil {
+      t.Errorf("expected an error calling %s", tt.fnName)
+      continue
+    }
+
+    if err.Error() != tt.expected {
+      t.Errorf("wrong error message. want=%q, got=%q", tt.expected, err.Error())
+    }
+  }
+}
+
+func testInteger(t *testing.T, obj object.Object, expected int64) {
+  t.Helper()
+
+  integer, ok := obj.(*object.Integer)
+  if !ok {
+    t.Fatalf("object is not Integer. got=%T (%+v)", obj, obj)
+  }
+
+  if integer.Value != expected {
+    t.Errorf("object has wrong value. want=%d, got=%d", expected, integer.Value)
+  }
+}
+
+func TestLimits(t *testing.T) {
+  interp := New()
+  interp.Limits.MaxSteps = 500
+
+  if _, err := interp.Eval(`let spin = fn(n) { spin(n + 1) };`); err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  var runtimeErr *RuntimeError
+
+  _, err := interp.Eval(`spin(0)`)
+  if !errors.As(err, &runtimeErr) || runtimeErr.Err.Message != "step limit exceeded: 500 steps" {
+    t.Errorf("expected the step limit to stop the script. got=%v", err)
+  }
+
+  // each call gets a fresh budget
+  result, err := interp.Eval(`1 + 1`)
+  if err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+  testInteger(t, result, 2)
+
+  _, err = interp.Call("spin", &object.Integer{Value: 0})
+  if !errors.As(err, &runtimeErr) || runtimeErr.Err.Message != "step limit exceeded: 500 steps" {
+    t.Errorf("expected the step limit to stop the call. got=%v", err)
+  }
+
+  ctx, cancel := context.WithCancel(context.Background())
+  cancel()
+
+  _, err = interp.EvalContext(ctx, `1 + 1`)
+  if !errors.As(err, &runtimeErr) || runtimeErr.Err.Message != "evaluation cancelled: context canceled" {
+    t.Errorf("expected the cancelled context to stop the script. got=%v", err)
+  }
+}
+
+func TestImportFromSearchPath(t *testing.T) {
+  dir := t.TempDir()
+  lib := `export let spin = fn(n) { while (n > 0) { n -= 1 } };`
+  if err := os.WriteFile(filepath.Join(dir, "spin.mk"), []byte(lib), 0o644); err != nil {
+    t.Fatal(err)
+  }
+
+  interp := New()
+  interp.SetSearchPaths(dir)
+
+  if _, err := interp.Eval(`let spin = (import "spin.mk")["spin"]; spin(3);`); err != nil {
+    t.Fatalf("unexpected error: %s", err)
+  }
+
+  // functions from an imported file count against the limits of the script calling them
+  interp.Limits.MaxSteps = 1000
+  _, err := interp.Eval(`spin(100000)`)
+  var runtimeErr *RuntimeError
+  if !errors.As(err, &runtimeErr) {
+    t.Fatalf("expected a *RuntimeError. got=%T (%v)", err, err)
+  }
+}
@@ -0,0 +1,67 @@
+package object
+
+import (
+	"context"
+)
+
+// DefaultMaxCallDepth is the call depth a Budget allows when none is given. It stops runaway recursion
+// well before the Go stack the evaluator runs on is exhausted.
+const DefaultMaxCallDepth = 10000
+
+// Budget bounds the work an evaluation may do: it can be cancelled through a context, and limited in the
+// number of steps it takes and how deeply its function calls nest. The evaluator counts against it as it
+// runs, so a Budget should only be used by one evaluation at a time.
+type Budget struct {
+	ctx          context.Context
+	maxSteps     int
+	maxCallDepth int
+
+	steps     int
+	callDepth int
+}
+
+// NewBudget returns a budget that runs out when ctx is done, after maxSteps steps, or when calls nest deeper
+// than maxCallDepth. A nil ctx is never done, maxSteps <= 0 means no step limit and maxCallDepth <= 0 means
+// DefaultMaxCallDepth.
+func NewBudget(ctx context.Context, maxSteps int, maxCallDepth int) *Budget {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if maxCallDepth <= 0 {
+		maxCallDepth = DefaultMaxCallDepth
+	}
+
+	return &Budget{ctx: ctx, maxSteps: maxSteps, maxCallDepth: maxCallDepth}
+}
+
+// Step counts one evaluation step, it returns an error once the context is done or the steps are used up.
+func (b *Budget) Step() *Error {
+	b.steps++
+
+	if b.maxSteps > 0 && b.steps > b.maxSteps {
+		return newError("step limit exceeded: %d steps", b.maxSteps)
+	}
+
+	select {
+	case <-b.ctx.Done():
+		return newError("evaluation cancelled: %s", b.ctx.Err())
+	default:
+		return nil
+	}
+}
+
+// Enter records a function call, it returns an error instead if the call would nest too deeply.
+// Every successful Enter has to be followed by a Leave when the call returns.
+func (b *Budget) Enter() *Error {
+	if b.callDepth >= b.maxCallDepth {
+		return newError("stack overflow: maximum call depth of %d exceeded", b.maxCallDepth)
+	}
+
+	b.callDepth++
+	return nil
+}
+
+func (b *Budget) Leave() {
+	b.callDepth--
+}
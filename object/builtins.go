@@ -0,0 +1,233 @@
+package object
+
+import (
+  "fmt"
+  "math"
+  "math/big"
+  "strconv"
+  "strings"
+)
+
+// Builtins is ordered, the compiler refers to each builtin by its index in this slice so new ones must be appended.
+var Builtins = []struct {
+  Name    string
+  Builtin *Builtin
+}{
+  {
+    "len",
+    &Builtin{
+      Fn: func(args ...Object) Object {
+        if len(args) != 1 {
+          return newError("wrong number of arguments. got=%d, expected=1", len(args))
+        }
+
+        switch arg := args[0].(type) {
+        case *String:
+          return &Integer{Value: int64(len(arg.Value))}
+      
+        case *Array:
+          return &Integer{Value: int64(len(arg.Elements))}
+        
+        default:
+          return newError("argument to `len` not supported, got %s", args[0].Type())
+        }
+      },
+    },
+  },
+  {
+    "first",
+    &Builtin{
+      Fn: func(args ...Object) Object {
+        if len(args) != 1 {
+          return newError("wrong number of arguments. got=%d, expected=1", len(args))
+        }
+
+        if args[0].Type() != ARRAY_OBJ {
+          return newError("argument to `first` must be ARRAY, got %s", args[0].Type())
+        }
+
+        arr := args[0].(*Array)
+        if len(arr.Elements) > 0 {
+          return arr.Elements[0]
+        }
+
+        return nil
+      },
+    },
+  },
+  {
+    "last",
+    &Builtin{
+      Fn: func(args ...Object) Object {
+        if len(args) != 1 {
+          return newError("wrong number of arguments. got=%d, expected=1", len(args))
+        }
+
+        if args[0].Type() != ARRAY_OBJ {
+          return newError("argument to `last` must be ARRAY, got %s", args[0].Type())
+        }
+
+        arr := args[0].(*Array)
+        length := len(arr.Elements)
+        if length > 0 {
+          return arr.Elements[length-1]
+        }
+
+        return nil
+      },
+    },
+  },
+  {
+    "rest",
+    &Builtin{
+      Fn: func(args ...Object) Object {
+        if len(args) != 1 {
+          return newError("wrong number of arguments. got=%d, expected=1", len(args))
+        }
+
+        if args[0].Type() != ARRAY_OBJ {
+          return newError("argument to `rest` must be ARRAY, got %s", args[0].Type())
+        }
+
+        arr := args[0].(*Array)
+        length := len(arr.Elements)
+        if length > 0 {
+          newElements := make([]Object, length-1, length-1)
+          copy(newElements, arr.Elements[1:length])
+          return &Array{Elements: newElements}
+        }
+
+        return nil
+      },
+    },
+  },
+  {
+    "push",
+    &Builtin{
+      Fn: func(args ...Object) Object {
+        if len(args) != 2 {
+          return newError("wrong number of arguments. got=%d, expected=2", len(args))
+        }
+
+        if args[0].Type() != ARRAY_OBJ {
+          return newError("argument to `push` must be ARRAY, got %s", args[0].Type())
+        }
+
+        arr := args[0].(*Array)
+        length := len(arr.Elements)
+
+        newElements := make([]Object, length+1, length+1)
+        copy(newElements, arr.Elements)
+        newElements[length] = args[1]
+
+        return &Array{Elements: newElements}
+      },
+    },
+  },
+  {
+    "puts",
+    &Builtin{
+      Fn: func(args ...Object) Object {
+        for _, arg := range args {
+          fmt.Println(arg.Inspect())
+        }
+
+        return nil
+      },
+    },
+  },
+  {
+    "float",
+    &Builtin{
+      Fn: func(args ...Object) Object {
+        if len(args) != 1 {
+          return newError("wrong number of arguments. got=%d, expected=1", len(args))
+        }
+
+        switch arg := args[0].(type) {
+        case *Float:
+          return arg
+
+        case *Integer:
+          return &Float{Value: float64(arg.Value)}
+
+        case *BigInteger:
+          value, _ := new(big.Float).SetInt(arg.Value).Float64()
+          return &Float{Value: value}
+
+        case *String:
+          value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+          if err != nil {
+            return newError("could not parse %q as FLOAT", arg.Value)
+          }
+          return &Float{Value: value}
+
+        default:
+          return newError("argument to `float` not supported, got %s", args[0].Type())
+        }
+      },
+    },
+  },
+  {
+    "int",
+    &Builtin{
+      Fn: func(args ...Object) Object {
+        if len(args) != 1 {
+          return newError("wrong number of arguments. got=%d, expected=1", len(args))
+        }
+
+        switch arg := args[0].(type) {
+        case *Integer, *BigInteger:
+          return arg
+
+        case *Float:
+          // truncates toward zero, like Go's conversion
+          if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+            return newError("cannot convert %s to INTEGER", arg.Inspect())
+          }
+          value, _ := big.NewFloat(arg.Value).Int(nil)
+          return IntegerFromBig(value)
+
+        case *String:
+          value, ok := new(big.Int).SetString(strings.TrimSpace(arg.Value), 10)
+          if !ok {
+            return newError("could not parse %q as INTEGER", arg.Value)
+          }
+          return IntegerFromBig(value)
+
+        default:
+          return newError("argument to `int` not supported, got %s", args[0].Type())
+        }
+      },
+    },
+  },
+  {"map", &Builtin{CallingFn: builtinMap}},
+  {"filter", &Builtin{CallingFn: builtinFilter}},
+  {"reduce", &Builtin{CallingFn: builtinReduce}},
+  {"each", &Builtin{CallingFn: builtinEach}},
+  {"find", &Builtin{CallingFn: builtinFind}},
+  {"any", &Builtin{CallingFn: builtinAny}},
+  {"all", &Builtin{CallingFn: builtinAll}},
+  {"sort", &Builtin{CallingFn: builtinSort}},
+  {"keys", &Builtin{Fn: builtinKeys}},
+  {"values", &Builtin{Fn: builtinValues}},
+  {"entries", &Builtin{Fn: builtinEntries}},
+  {"has", &Builtin{Fn: builtinHas}},
+  {"delete", &Builtin{Fn: builtinDelete}},
+  {"merge", &Builtin{Fn: builtinMerge}},
+  {"size", &Builtin{Fn: builtinSize}},
+}
+
+func GetBuiltinByName(name string) *Builtin {
+  for _, def := range Builtins {
+    if def.Name == name {
+      return def.Builtin
+    }
+  }
+
+  return nil
+}
+
+func newError(format string, a ...interface{}) *Error {
+  return &Error{Message: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,276 @@
+package object
+
+import (
+  "math"
+  "math/big"
+  "sort"
+  "strings"
+)
+
+// The collection builtins call the function they are passed once per element, in order, and stop at
+// the first error it returns. They never change the array they are given.
+
+// arrayAndFunction checks the arguments of a builtin that takes an array and a function to call on it.
+func arrayAndFunction(name string, args []Object, want int) (*Array, Object, *Error) {
+  if len(args) != want {
+    return nil, nil, newError("wrong number of arguments. got=%d, expected=%d", len(args), want)
+  }
+
+  arr, ok := args[0].(*Array)
+  if !ok {
+    return nil, nil, newError("argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+  }
+
+  if !isCallable(args[1]) {
+    return nil, nil, newError("argument to `%s` must be FUNCTION, got %s", name, args[1].Type())
+  }
+
+  return arr, args[1], nil
+}
+
+func isCallable(obj Object) bool {
+  return obj.Type() == FUNCTION_OBJ || obj.Type() == BUILTIN_OBJ
+}
+
+func isErrorObject(obj Object) bool {
+  return obj != nil && obj.Type() == ERROR_OBJ
+}
+
+func builtinMap(call Caller, args ...Object) Object {
+  arr, fn, err := arrayAndFunction("map", args, 2)
+  if err != nil {
+    return err
+  }
+
+  elements := make([]Object, len(arr.Elements))
+  for i, element := range arr.Elements {
+    result := call(fn, element)
+    if isErrorObject(result) {
+      return result
+    }
+    elements[i] = result
+  }
+
+  return &Array{Elements: elements}
+}
+
+func builtinFilter(call Caller, args ...Object) Object {
+  arr, fn, err := arrayAndFunction("filter", args, 2)
+  if err != nil {
+    return err
+  }
+
+  elements := []Object{}
+  for _, element := range arr.Elements {
+    result := call(fn, element)
+    if isErrorObject(result) {
+      return result
+    }
+    if IsTruthy(result) {
+      elements = append(elements, element)
+    }
+  }
+
+  return &Array{Elements: elements}
+}
+
+// builtinReduce folds the array from the left, reduce(arr, fn(acc, x) { ... }, initial). Without an
+// initial value the first element is the starting accumulator.
+func builtinReduce(call Caller, args ...Object) Object {
+  if len(args) != 2 && len(args) != 3 {
+    return newError("wrong number of arguments. got=%d, expected=2 or 3", len(args))
+  }
+
+  arr, fn, err := arrayAndFunction("reduce", args[:2], 2)
+  if err != nil {
+    return err
+  }
+
+  elements := arr.Elements
+  var acc Object
+  if len(args) == 3 {
+    acc = args[2]
+  } else {
+    if len(elements) == 0 {
+      return newError("`reduce` of an empty array needs an initial value")
+    }
+    acc, elements = elements[0], elements[1:]
+  }
+
+  for _, element := range elements {
+    acc = call(fn, acc, element)
+    if isErrorObject(acc) {
+      return acc
+    }
+  }
+
+  return acc
+}
+
+func builtinEach(call Caller, args ...Object) Object {
+  arr, fn, err := arrayAndFunction("each", args, 2)
+  if err != nil {
+    return err
+  }
+
+  for _, element := range arr.Elements {
+    if result := call(fn, element); isErrorObject(result) {
+      return result
+    }
+  }
+
+  return NULL
+}
+
+// builtinFind returns the first element the function is truthy for, null when there is none.
+func builtinFind(call Caller, args ...Object) Object {
+  arr, fn, err := arrayAndFunction("find", args, 2)
+  if err != nil {
+    return err
+  }
+
+  for _, element := range arr.Elements {
+    result := call(fn, element)
+    if isErrorObject(result) {
+      return result
+    }
+    if IsTruthy(result) {
+      return element
+    }
+  }
+
+  return NULL
+}
+
+func builtinAny(call Caller, args ...Object) Object {
+  arr, fn, err := arrayAndFunction("any", args, 2)
+  if err != nil {
+    return err
+  }
+
+  for _, element := range arr.Elements {
+    result := call(fn, element)
+    if isErrorObject(result) {
+      return result
+    }
+    if IsTruthy(result) {
+      return TRUE
+    }
+  }
+
+  return FALSE
+}
+
+func builtinAll(call Caller, args ...Object) Object {
+  arr, fn, err := arrayAndFunction("all", args, 2)
+  if err != nil {
+    return err
+  }
+
+  for _, element := range arr.Elements {
+    result := call(fn, element)
+    if isErrorObject(result) {
+      return result
+    }
+    if !IsTruthy(result) {
+      return FALSE
+    }
+  }
+
+  return TRUE
+}
+
+// builtinSort returns the elements in ascending order, keeping equal ones in their original order. A
+// comparator, when given, is called as less(a, b) and is truthy when a belongs before b. Without one
+// only numbers and only strings can be sorted.
+func builtinSort(call Caller, args ...Object) Object {
+  if len(args) != 1 && len(args) != 2 {
+    return newError("wrong number of arguments. got=%d, expected=1 or 2", len(args))
+  }
+
+  arr, ok := args[0].(*Array)
+  if !ok {
+    return newError("argument to `sort` must be ARRAY, got %s", args[0].Type())
+  }
+
+  less := compareObjects
+  if len(args) == 2 {
+    fn := args[1]
+    if !isCallable(fn) {
+      return newError("argument to `sort` must be FUNCTION, got %s", fn.Type())
+    }
+    less = func(a, b Object) (bool, Object) {
+      result := call(fn, a, b)
+      if isErrorObject(result) {
+        return false, result
+      }
+      return IsTruthy(result), nil
+    }
+  }
+
+  elements := make([]Object, len(arr.Elements))
+  copy(elements, arr.Elements)
+
+  // sort.SliceStable can't be stopped, so after an error the remaining comparisons are skipped
+  var err Object
+  sort.SliceStable(elements, func(i, j int) bool {
+    if err != nil {
+      return false
+    }
+    result, e := less(elements[i], elements[j])
+    err = e
+    return result
+  })
+
+  if err != nil {
+    return err
+  }
+
+  return &Array{Elements: elements}
+}
+
+// compareObjects orders numbers by value and strings byte by byte.
+func compareObjects(a, b Object) (bool, Object) {
+  switch a := a.(type) {
+  case *String:
+    if b, ok := b.(*String); ok {
+      return strings.Compare(a.Value, b.Value) < 0, nil
+    }
+  case *Integer:
+    if b, ok := b.(*Integer); ok {
+      return a.Value < b.Value, nil
+    }
+  }
+
+  if isNumberObject(a) && isNumberObject(b) {
+    if isNaN(a) || isNaN(b) {
+      return false, nil // NaN is unordered, it stays where it was
+    }
+    return numberValue(a).Cmp(numberValue(b)) < 0, nil
+  }
+
+  return false, newError("`sort` can't compare %s and %s without a comparator", a.Type(), b.Type())
+}
+
+func isNumberObject(obj Object) bool {
+  return obj.Type() == INTEGER_OBJ || obj.Type() == FLOAT_OBJ
+}
+
+func isNaN(obj Object) bool {
+  f, ok := obj.(*Float)
+  return ok && math.IsNaN(f.Value)
+}
+
+// numberValue widens an integer or float so any two can be compared exactly.
+func numberValue(obj Object) *big.Float {
+  switch obj := obj.(type) {
+  case *Integer:
+    return new(big.Float).SetInt64(obj.Value)
+  case *BigInteger:
+    return new(big.Float).SetInt(obj.Value)
+  case *Float:
+    return big.NewFloat(obj.Value)
+  default:
+    return new(big.Float)
+  }
+}
@@ -0,0 +1,541 @@
+package object
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// FromGo converts a Go value to the Monkey object it corresponds to:
+//
+//	nil, nil pointers and nil interfaces  -> null
+//	bool                                  -> BOOLEAN
+//	signed and unsigned integers          -> INTEGER, a BigInteger past the range of int64
+//	*big.Int                              -> INTEGER
+//	float32 and float64                   -> FLOAT
+//	string                                -> STRING
+//	slices and arrays                     -> ARRAY
+//	maps                                  -> HASH, the keys must convert to hashable objects
+//	structs                               -> HASH keyed by field name, see below
+//	funcs                                 -> a *Builtin, see below
+//
+// Values that already are an Object are returned as they are, pointers are followed.
+//
+// A struct's exported fields become the hash's pairs. The `monkey` struct tag renames a field,
+// `monkey:"-"` leaves it out.
+//
+// A func becomes a builtin that converts its arguments with ToGo, calls the func and converts the
+// result back with FromGo. The func may return nothing, a value, an error, or a value and an error;
+// a non-nil error is handed to the script as a Monkey error.
+func FromGo(value any) (Object, error) {
+	return fromGo(reflect.ValueOf(value))
+}
+
+var (
+	objectType = reflect.TypeOf((*Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	bigIntType = reflect.TypeOf((*big.Int)(nil))
+)
+
+func fromGo(v reflect.Value) (Object, error) {
+	if !v.IsValid() {
+		return NULL, nil
+	}
+
+	if v.Type().Implements(objectType) {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return NULL, nil
+		}
+		return v.Interface().(Object), nil
+	}
+
+	if v.Type() == bigIntType && !v.IsNil() {
+		return IntegerFromBig(new(big.Int).Set(v.Interface().(*big.Int))), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return NULL, nil
+		}
+		return fromGo(v.Elem())
+
+	case reflect.Bool:
+		if v.Bool() {
+			return TRUE, nil
+		}
+		return FALSE, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Integer{Value: v.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return IntegerFromBig(new(big.Int).SetUint64(v.Uint())), nil
+
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: v.Float()}, nil
+
+	case reflect.String:
+		return &String{Value: v.String()}, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return NULL, nil
+		}
+
+		elements := make([]Object, v.Len())
+		for i := range elements {
+			el, err := fromGo(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+			elements[i] = el
+		}
+		return &Array{Elements: elements}, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return NULL, nil
+		}
+
+		// a Go map has no order, the pairs are sorted by key so converting the same map gives the same hash
+		pairs := []HashPair{}
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := fromGo(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := key.(Hashable); !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+
+			value, err := fromGo(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", key.Inspect(), err)
+			}
+
+			pairs = append(pairs, HashPair{Key: key, Value: value})
+		}
+		sortPairs(pairs)
+
+		hash := NewHash()
+		for _, pair := range pairs {
+			hash.Set(pair.Key.(Hashable).HashKey(), pair)
+		}
+		return hash, nil
+
+	case reflect.Struct:
+		hash := NewHash()
+		for _, field := range structFields(v.Type()) {
+			fv, ok := fieldByIndex(v, field.index, false)
+			if !ok {
+				continue
+			}
+
+			value, err := fromGo(fv)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+
+			key := &String{Value: field.name}
+			hash.Set(key.HashKey(), HashPair{Key: key, Value: value})
+		}
+		return hash, nil
+
+	case reflect.Func:
+		if v.IsNil() {
+			return NULL, nil
+		}
+		return wrapFunc(v)
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to a Monkey value", v.Type())
+}
+
+// ToGo stores obj in the value target points to, converting it to target's type. It is the reverse
+// of FromGo and follows the same rules, with unknown hash keys ignored when filling a struct. An
+// empty interface target gets the natural Go value: int64, *big.Int for a BigInteger, float64, bool,
+// string, nil, []any, and map[string]any for hashes with only string keys, map[any]any for any other
+// hash. Objects with no Go counterpart, like functions, are stored as they are.
+func ToGo(obj Object, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("ToGo needs a non-nil pointer, got %T", target)
+	}
+
+	return toGo(obj, v.Elem())
+}
+
+func toGo(obj Object, v reflect.Value) error {
+	t := v.Type()
+
+	emptyInterface := t.Kind() == reflect.Interface && t.NumMethod() == 0
+	if !emptyInterface && reflect.TypeOf(obj).AssignableTo(t) {
+		v.Set(reflect.ValueOf(obj))
+		return nil
+	}
+
+	if t == bigIntType {
+		switch n := obj.(type) {
+		case *Integer:
+			v.Set(reflect.ValueOf(big.NewInt(n.Value)))
+			return nil
+		case *BigInteger:
+			v.Set(reflect.ValueOf(new(big.Int).Set(n.Value)))
+			return nil
+		}
+	}
+
+	if _, ok := obj.(*Null); ok {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if !emptyInterface {
+			break
+		}
+		natural, err := naturalGo(obj)
+		if err != nil {
+			return err
+		}
+		if natural == nil {
+			v.Set(reflect.Zero(t))
+		} else {
+			v.Set(reflect.ValueOf(natural))
+		}
+		return nil
+
+	case reflect.Pointer:
+		elem := reflect.New(t.Elem())
+		if err := toGo(obj, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+
+	case reflect.Bool:
+		if b, ok := obj.(*Boolean); ok {
+			v.SetBool(b.Value)
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := obj.(*Integer); ok {
+			if v.OverflowInt(i.Value) {
+				return fmt.Errorf("cannot convert %d to %s: out of range", i.Value, t)
+			}
+			v.SetInt(i.Value)
+			return nil
+		}
+		if i, ok := obj.(*BigInteger); ok {
+			return fmt.Errorf("cannot convert %s to %s: out of range", i.Inspect(), t)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*Integer); ok {
+			if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+				return fmt.Errorf("cannot convert %d to %s: out of range", i.Value, t)
+			}
+			v.SetUint(uint64(i.Value))
+			return nil
+		}
+		if i, ok := obj.(*BigInteger); ok {
+			if !i.Value.IsUint64() || v.OverflowUint(i.Value.Uint64()) {
+				return fmt.Errorf("cannot convert %s to %s: out of range", i.Inspect(), t)
+			}
+			v.SetUint(i.Value.Uint64())
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *Float:
+			if v.OverflowFloat(n.Value) {
+				return fmt.Errorf("cannot convert %s to %s: out of range", n.Inspect(), t)
+			}
+			v.SetFloat(n.Value)
+			return nil
+		case *Integer:
+			v.SetFloat(float64(n.Value))
+			return nil
+		case *BigInteger:
+			f, _ := new(big.Float).SetInt(n.Value).Float64()
+			v.SetFloat(f)
+			return nil
+		}
+
+	case reflect.String:
+		if s, ok := obj.(*String); ok {
+			v.SetString(s.Value)
+			return nil
+		}
+
+	case reflect.Slice:
+		if arr, ok := obj.(*Array); ok {
+			slice := reflect.MakeSlice(t, len(arr.Elements), len(arr.Elements))
+			for i, el := range arr.Elements {
+				if err := toGo(el, slice.Index(i)); err != nil {
+					return fmt.Errorf("index %d: %w", i, err)
+				}
+			}
+			v.Set(slice)
+			return nil
+		}
+
+	case reflect.Array:
+		if arr, ok := obj.(*Array); ok {
+			if len(arr.Elements) != t.Len() {
+				return fmt.Errorf("cannot convert ARRAY of length %d to %s", len(arr.Elements), t)
+			}
+			for i, el := range arr.Elements {
+				if err := toGo(el, v.Index(i)); err != nil {
+					return fmt.Errorf("index %d: %w", i, err)
+				}
+			}
+			return nil
+		}
+
+	case reflect.Map:
+		if hash, ok := obj.(*Hash); ok {
+			m := reflect.MakeMapWithSize(t, len(hash.Pairs))
+			for _, pair := range hash.OrderedPairs() {
+				key := reflect.New(t.Key()).Elem()
+				if err := toGo(pair.Key, key); err != nil {
+					return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+				}
+				value := reflect.New(t.Elem()).Elem()
+				if err := toGo(pair.Value, value); err != nil {
+					return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+				}
+				m.SetMapIndex(key, value)
+			}
+			v.Set(m)
+			return nil
+		}
+
+	case reflect.Struct:
+		if hash, ok := obj.(*Hash); ok {
+			for _, field := range structFields(t) {
+				key := &String{Value: field.name}
+				pair, ok := hash.Pairs[key.HashKey()]
+				if !ok {
+					continue
+				}
+				fv, ok := fieldByIndex(v, field.index, true)
+				if !ok {
+					return fmt.Errorf("field %s: cannot set field of nil embedded pointer", field.name)
+				}
+				if err := toGo(pair.Value, fv); err != nil {
+					return fmt.Errorf("field %s: %w", field.name, err)
+				}
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot convert %s to %s", obj.Type(), t)
+}
+
+// naturalGo is the Go value an object turns into when the target doesn't ask for a particular type.
+func naturalGo(obj Object) (any, error) {
+	switch obj := obj.(type) {
+	case *Null:
+		return nil, nil
+	case *Boolean:
+		return obj.Value, nil
+	case *Integer:
+		return obj.Value, nil
+	case *BigInteger:
+		return new(big.Int).Set(obj.Value), nil
+	case *Float:
+		return obj.Value, nil
+	case *String:
+		return obj.Value, nil
+
+	case *Array:
+		elements := make([]any, len(obj.Elements))
+		for i, el := range obj.Elements {
+			natural, err := naturalGo(el)
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+			elements[i] = natural
+		}
+		return elements, nil
+
+	case *Hash:
+		stringKeys := true
+		for _, pair := range obj.OrderedPairs() {
+			if _, ok := pair.Key.(*String); !ok {
+				stringKeys = false
+				break
+			}
+		}
+
+		if stringKeys {
+			m := make(map[string]any, len(obj.Pairs))
+			for _, pair := range obj.OrderedPairs() {
+				natural, err := naturalGo(pair.Value)
+				if err != nil {
+					return nil, fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+				}
+				m[pair.Key.(*String).Value] = natural
+			}
+			return m, nil
+		}
+
+		m := make(map[any]any, len(obj.Pairs))
+		for _, pair := range obj.OrderedPairs() {
+			key, _ := naturalGo(pair.Key) // hash keys are always integers, booleans or strings
+			natural, err := naturalGo(pair.Value)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+			}
+			m[key] = natural
+		}
+		return m, nil
+	}
+
+	return obj, nil
+}
+
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFields lists the fields of t that take part in conversion. Fields of embedded structs are
+// promoted the way Go promotes them, unless the embedded field has a tag name of its own, and a name
+// used at several depths goes to the shallowest field, like encoding/json does.
+func structFields(t reflect.Type) []structField {
+	candidates := []structField{}
+
+	for _, f := range reflect.VisibleFields(t) {
+		tag := f.Tag.Get("monkey")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				continue // VisibleFields lists its promoted fields as well
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		candidates = append(candidates, structField{name: name, index: f.Index})
+	}
+
+	// candidates are in VisibleFields order, that is by index path, which keeps the Go declaration
+	// order with the fields of an embedded struct where it is embedded
+	winner := map[string]int{}
+	for i, field := range candidates {
+		w, ok := winner[field.name]
+		if !ok || len(field.index) < len(candidates[w].index) {
+			winner[field.name] = i
+		}
+	}
+
+	fields := []structField{}
+	for i, field := range candidates {
+		if winner[field.name] == i {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex for fields promoted through embedded pointers. When
+// reading it reports false for a nil pointer on the way, when writing it allocates it.
+func fieldByIndex(v reflect.Value, index []int, write bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !write || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+func wrapFunc(fn reflect.Value) (*Builtin, error) {
+	t := fn.Type()
+
+	switch {
+	case t.NumOut() > 2,
+		t.NumOut() == 2 && t.Out(1) != errorType:
+		return nil, fmt.Errorf("cannot convert %s to a builtin: it must return at most a value and an error", t)
+	}
+
+	return &Builtin{Fn: func(args ...Object) Object {
+		fixed := t.NumIn()
+		if t.IsVariadic() {
+			fixed--
+		}
+
+		if len(args) < fixed || (!t.IsVariadic() && len(args) != fixed) {
+			return newError("wrong number of arguments. got=%d, expected=%d", len(args), fixed)
+		}
+
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var paramType reflect.Type
+			if i >= fixed {
+				paramType = t.In(fixed).Elem()
+			} else {
+				paramType = t.In(i)
+			}
+
+			param := reflect.New(paramType).Elem()
+			if err := toGo(arg, param); err != nil {
+				return newError("argument %d: %s", i+1, err)
+			}
+			in[i] = param
+		}
+
+		out := fn.Call(in)
+
+		if len(out) > 0 && t.Out(len(out)-1) == errorType {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return newError("%s", err)
+			}
+			out = out[:len(out)-1]
+		}
+
+		if len(out) == 0 {
+			return nil
+		}
+
+		result, err := fromGo(out[0])
+		if err != nil {
+			return newError("%s", err)
+		}
+		return result
+	}}, nil
+}
@@ -0,0 +1,394 @@
+package object
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	City string `monkey:"city"`
+	Zip  string `monkey:"-"`
+}
+
+type Meta struct {
+	Version int
+}
+
+type person struct {
+	Meta
+	Name    string   `monkey:"name"`
+	Age     uint8    `monkey:"age"`
+	Tags    []string `monkey:"tags"`
+	Address *address `monkey:"address"`
+	secret  string
+}
+
+func TestFromGoScalars(t *testing.T) {
+	var nilPtr *int
+	var nilObj *Integer
+
+	tests := []struct {
+		input    any
+		expected Object
+	}{
+		{nil, NULL},
+		{nilPtr, NULL},
+		{nilObj, NULL},
+		{true, TRUE},
+		{false, FALSE},
+		{5, &Integer{Value: 5}},
+		{int8(-3), &Integer{Value: -3}},
+		{uint32(7), &Integer{Value: 7}},
+		{uint64(math.MaxUint64), &BigInteger{Value: new(big.Int).SetUint64(math.MaxUint64)}},
+		{big.NewInt(12), &Integer{Value: 12}},
+		{1.5, &Float{Value: 1.5}},
+		{float32(0.25), &Float{Value: 0.25}},
+		{"monkey", &String{Value: "monkey"}},
+		{&String{Value: "as is"}, &String{Value: "as is"}},
+	}
+
+	for _, tt := range tests {
+		obj, err := FromGo(tt.input)
+		if err != nil {
+			t.Errorf("FromGo(%#v) returned an error: %s", tt.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(obj, tt.expected) {
+			t.Errorf("FromGo(%#v) wrong. want=%s, got=%s", tt.input, tt.expected.Inspect(), obj.Inspect())
+		}
+	}
+
+	// the engines compare booleans and null by identity
+	if obj, _ := FromGo(false); obj != FALSE {
+		t.Errorf("FromGo(false) is not FALSE")
+	}
+	if obj, _ := FromGo(nil); obj != NULL {
+		t.Errorf("FromGo(nil) is not NULL")
+	}
+}
+
+func TestFromGoErrors(t *testing.T) {
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{make(chan int), "cannot convert chan int to a Monkey value"},
+		{[]any{1, make(chan int)}, "index 1: cannot convert chan int to a Monkey value"},
+		{map[[2]int]int{{1, 2}: 3}, "unusable as hash key: ARRAY"},
+		{func() (int, int) { return 0, 0 }, "cannot convert func() (int, int) to a builtin: it must return at most a value and an error"},
+	}
+
+	for _, tt := range tests {
+		_, err := FromGo(tt.input)
+		if err == nil {
+			t.Errorf("FromGo(%T) expected an error", tt.input)
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
+
+func TestFromGoCollections(t *testing.T) {
+	obj, err := FromGo(map[string]any{
+		"list":   []int{1, 2},
+		"nested": map[int]bool{1: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	hash, ok := obj.(*Hash)
+	if !ok {
+		t.Fatalf("object is not Hash. got=%T", obj)
+	}
+
+	list := hash.Pairs[(&String{Value: "list"}).HashKey()].Value
+	if list.Inspect() != "[1, 2]" {
+		t.Errorf("wrong list. got=%s", list.Inspect())
+	}
+
+	nested := hash.Pairs[(&String{Value: "nested"}).HashKey()].Value
+	if nested.Inspect() != "{1: true}" {
+		t.Errorf("wrong nested hash. got=%s", nested.Inspect())
+	}
+}
+
+func TestFromGoStruct(t *testing.T) {
+	p := person{
+		Meta:    Meta{Version: 2},
+		Name:    "Ada",
+		Age:     36,
+		Tags:    []string{"math"},
+		Address: &address{City: "London", Zip: "N1"},
+		secret:  "hidden",
+	}
+
+	obj, err := FromGo(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	hash := obj.(*Hash)
+
+	expected := map[string]string{
+		"Version": "2",
+		"name":    "Ada",
+		"age":     "36",
+		"tags":    "[math]",
+		"address": "{city: London}",
+	}
+
+	if len(hash.Pairs) != len(expected) {
+		t.Errorf("wrong number of pairs. want=%d, got=%d (%s)", len(expected), len(hash.Pairs), hash.Inspect())
+	}
+
+	for key, value := range expected {
+		pair, ok := hash.Pairs[(&String{Value: key}).HashKey()]
+		if !ok {
+			t.Errorf("no pair for key %q", key)
+			continue
+		}
+
+		if pair.Value.Inspect() != value {
+			t.Errorf("wrong value for %q. want=%s, got=%s", key, value, pair.Value.Inspect())
+		}
+	}
+}
+
+func TestFromGoStructFieldOrder(t *testing.T) {
+	type inner struct {
+		B int
+		C int
+		E int
+	}
+	type outer struct {
+		A int
+		inner
+		D int
+		E string
+	}
+
+	obj, err := FromGo(outer{A: 1, inner: inner{B: 2, C: 3, E: 4}, D: 5, E: "e"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// declaration order, with the promoted fields where the struct is embedded and E from outer
+	expected := "{A: 1, B: 2, C: 3, D: 5, E: e}"
+	if obj.Inspect() != expected {
+		t.Errorf("wrong field order. want=%s, got=%s", expected, obj.Inspect())
+	}
+}
+
+func TestFromGoFunc(t *testing.T) {
+	obj, err := FromGo(func(sep string, parts ...string) string {
+		return strings.Join(parts, sep)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	join, ok := obj.(*Builtin)
+	if !ok {
+		t.Fatalf("object is not Builtin. got=%T", obj)
+	}
+
+	result := join.Fn(&String{Value: "-"}, &String{Value: "a"}, &String{Value: "b"})
+	if result.Inspect() != "a-b" {
+		t.Errorf("wrong result. got=%s", result.Inspect())
+	}
+
+	tests := []struct {
+		fn       any
+		args     []Object
+		expected string
+	}{
+		{
+			func(sep string, parts ...string) string { return "" },
+			[]Object{},
+			"ERROR: wrong number of arguments. got=0, expected=1",
+		},
+		{
+			func(n int) int { return n },
+			[]Object{&String{Value: "1"}},
+			"ERROR: argument 1: cannot convert STRING to int",
+		},
+		{
+			func(n int) (int, error) { return 0, errors.New("negative") },
+			[]Object{&Integer{Value: -1}},
+			"ERROR: negative",
+		},
+		{
+			func(n int) (int, error) { return n * 2, nil },
+			[]Object{&Integer{Value: 4}},
+			"8",
+		},
+		{
+			func() {},
+			[]Object{},
+			"<nil>",
+		},
+	}
+
+	for _, tt := range tests {
+		obj, err := FromGo(tt.fn)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		result := obj.(*Builtin).Fn(tt.args...)
+
+		got := "<nil>"
+		if result != nil {
+			got = result.Inspect()
+		}
+
+		if got != tt.expected {
+			t.Errorf("wrong result. want=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestToGo(t *testing.T) {
+	var i int
+	if err := ToGo(&Integer{Value: 42}, &i); err != nil || i != 42 {
+		t.Errorf("ToGo int wrong. got=%d, err=%v", i, err)
+	}
+
+	var s []string
+	arr := &Array{Elements: []Object{&String{Value: "a"}, &String{Value: "b"}}}
+	if err := ToGo(arr, &s); err != nil || !reflect.DeepEqual(s, []string{"a", "b"}) {
+		t.Errorf("ToGo []string wrong. got=%v, err=%v", s, err)
+	}
+
+	var ptr *int
+	if err := ToGo(NULL, &ptr); err != nil || ptr != nil {
+		t.Errorf("ToGo null wrong. got=%v, err=%v", ptr, err)
+	}
+
+	var b *big.Int
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	if err := ToGo(IntegerFromBig(huge), &b); err != nil || b.Cmp(huge) != 0 {
+		t.Errorf("ToGo *big.Int wrong. got=%v, err=%v", b, err)
+	}
+
+	var u64 uint64
+	if err := ToGo(IntegerFromBig(new(big.Int).SetUint64(math.MaxUint64)), &u64); err != nil || u64 != math.MaxUint64 {
+		t.Errorf("ToGo uint64 wrong. got=%d, err=%v", u64, err)
+	}
+
+	var f float64
+	if err := ToGo(&Integer{Value: 3}, &f); err != nil || f != 3 {
+		t.Errorf("ToGo float64 wrong. got=%g, err=%v", f, err)
+	}
+
+	var obj Object
+	if err := ToGo(TRUE, &obj); err != nil || obj != TRUE {
+		t.Errorf("ToGo Object wrong. got=%v, err=%v", obj, err)
+	}
+}
+
+func TestToGoRoundTrip(t *testing.T) {
+	in := person{
+		Meta:    Meta{Version: 3},
+		Name:    "Grace",
+		Age:     85,
+		Tags:    []string{"cobol", "navy"},
+		Address: &address{City: "Arlington"},
+	}
+
+	obj, err := FromGo(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out person
+	if err := ToGo(obj, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip changed the value. want=%+v, got=%+v", in, out)
+	}
+}
+
+func TestToGoNatural(t *testing.T) {
+	obj, err := FromGo(map[string]any{
+		"n":    1,
+		"x":    0.5,
+		"ok":   true,
+		"list": []any{"x", nil},
+		"ids":  map[int]string{7: "seven"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out any
+	if err := ToGo(obj, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]any{
+		"n":    int64(1),
+		"x":    0.5,
+		"ok":   true,
+		"list": []any{"x", nil},
+		"ids":  map[any]any{int64(7): "seven"},
+	}
+
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("wrong value. want=%#v, got=%#v", expected, out)
+	}
+}
+
+func TestToGoErrors(t *testing.T) {
+	var i int
+	var u uint
+	var i8 int8
+	var f32 float32
+	var arr [2]int
+	var p person
+
+	tests := []struct {
+		obj      Object
+		target   any
+		expected string
+	}{
+		{&Integer{Value: 1}, i, "ToGo needs a non-nil pointer, got int"},
+		{&String{Value: "1"}, &i, "cannot convert STRING to int"},
+		{&Integer{Value: -1}, &u, "cannot convert -1 to uint: out of range"},
+		{&Integer{Value: 300}, &i8, "cannot convert 300 to int8: out of range"},
+		{IntegerFromBig(new(big.Int).Lsh(big.NewInt(1), 64)), &i, "cannot convert 18446744073709551616 to int: out of range"},
+		{&Float{Value: 1e300}, &f32, "cannot convert 1e+300 to float32: out of range"},
+		{&Float{Value: 1.5}, &i, "cannot convert FLOAT to int"},
+		{&Array{Elements: []Object{}}, &arr, "cannot convert ARRAY of length 0 to [2]int"},
+		{
+			&Hash{Pairs: map[HashKey]HashPair{
+				(&String{Value: "age"}).HashKey(): {Key: &String{Value: "age"}, Value: &Integer{Value: 1000}},
+			}},
+			&p,
+			"field age: cannot convert 1000 to uint8: out of range",
+		},
+	}
+
+	for _, tt := range tests {
+		err := ToGo(tt.obj, tt.target)
+		if err == nil {
+			t.Errorf("ToGo(%s, %T) expected an error", tt.obj.Inspect(), tt.target)
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
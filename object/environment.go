@@ -11,9 +11,20 @@ func NewEnvironment() *Environment {
 	return &Environment{store: s, outer: nil}
 }
 
+// NewModuleEnvironment returns the environment an imported file runs in. It shares no bindings with
+// host, the environment of the import, but it runs under host's budget and modules.
+func NewModuleEnvironment(host *Environment) *Environment {
+	env := NewEnvironment()
+	env.host = host
+	return env
+}
+
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store   map[string]Object
+	outer   *Environment
+	host    *Environment
+	budget  *Budget
+	modules *Modules
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -28,4 +39,54 @@ func (e *Environment) Get(name string) (Object, bool) {
 func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
-}
\ No newline at end of file
+}
+
+// Budget returns the budget of the evaluation running in this environment. It is looked up on the
+// outermost environment every time, so functions defined by an earlier evaluation count against the
+// budget of the one calling them. It is nil when no budget has been set.
+func (e *Environment) Budget() *Budget {
+	return e.root().budget
+}
+
+// SetBudget makes b the budget for everything evaluated in this environment and the environments it encloses.
+func (e *Environment) SetBudget(b *Budget) {
+	e.root().budget = b
+}
+
+// Modules returns the modules imported by the evaluation running in this environment, like Budget it is
+// kept on the outermost environment. It is nil when no modules have been set.
+func (e *Environment) Modules() *Modules {
+	return e.root().modules
+}
+
+// SetModules makes m the modules for everything evaluated in this environment and the environments it encloses.
+func (e *Environment) SetModules(m *Modules) {
+	e.root().modules = m
+}
+
+// root is the outermost environment, past the environments of any imported files to the one importing them.
+func (e *Environment) root() *Environment {
+	for {
+		switch {
+		case e.outer != nil:
+			e = e.outer
+		case e.host != nil:
+			e = e.host
+		default:
+			return e
+		}
+	}
+}
+
+// Assign updates name in the innermost environment that defines it, rather than shadowing it in e.
+// It reports false, and changes nothing, when no enclosing environment defines name.
+func (e *Environment) Assign(name string, val Object) (Object, bool) {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			env.store[name] = val
+			return val, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,161 @@
+package object
+
+// The hash builtins never change the hash they are given, delete and merge build a new one. Keys,
+// values and entries come out in the hash's order, the order its keys were added in.
+
+func hashArg(name string, arg Object) (*Hash, *Error) {
+  hash, ok := arg.(*Hash)
+  if !ok {
+    return nil, newError("argument to `%s` must be HASH, got %s", name, arg.Type())
+  }
+
+  return hash, nil
+}
+
+func hashKeyArg(arg Object) (HashKey, *Error) {
+  key, ok := arg.(Hashable)
+  if !ok {
+    return HashKey{}, newError("unusable as hash key: %s", arg.Type())
+  }
+
+  return key.HashKey(), nil
+}
+
+func copyHash(hash *Hash) *Hash {
+  copied := NewHash()
+  for _, pair := range hash.OrderedPairs() {
+    copied.Set(pair.Key.(Hashable).HashKey(), pair)
+  }
+
+  return copied
+}
+
+func builtinKeys(args ...Object) Object {
+  if len(args) != 1 {
+    return newError("wrong number of arguments. got=%d, expected=1", len(args))
+  }
+
+  hash, err := hashArg("keys", args[0])
+  if err != nil {
+    return err
+  }
+
+  keys := []Object{}
+  for _, pair := range hash.OrderedPairs() {
+    keys = append(keys, pair.Key)
+  }
+
+  return &Array{Elements: keys}
+}
+
+func builtinValues(args ...Object) Object {
+  if len(args) != 1 {
+    return newError("wrong number of arguments. got=%d, expected=1", len(args))
+  }
+
+  hash, err := hashArg("values", args[0])
+  if err != nil {
+    return err
+  }
+
+  values := []Object{}
+  for _, pair := range hash.OrderedPairs() {
+    values = append(values, pair.Value)
+  }
+
+  return &Array{Elements: values}
+}
+
+// builtinEntries returns the pairs of a hash as [key, value] arrays.
+func builtinEntries(args ...Object) Object {
+  if len(args) != 1 {
+    return newError("wrong number of arguments. got=%d, expected=1", len(args))
+  }
+
+  hash, err := hashArg("entries", args[0])
+  if err != nil {
+    return err
+  }
+
+  entries := []Object{}
+  for _, pair := range hash.OrderedPairs() {
+    entries = append(entries, &Array{Elements: []Object{pair.Key, pair.Value}})
+  }
+
+  return &Array{Elements: entries}
+}
+
+// builtinHas tells a missing key apart from one holding null, which indexing can't.
+func builtinHas(args ...Object) Object {
+  if len(args) != 2 {
+    return newError("wrong number of arguments. got=%d, expected=2", len(args))
+  }
+
+  hash, err := hashArg("has", args[0])
+  if err != nil {
+    return err
+  }
+
+  key, err := hashKeyArg(args[1])
+  if err != nil {
+    return err
+  }
+
+  _, ok := hash.Pairs[key]
+  return NativeBool(ok)
+}
+
+func builtinDelete(args ...Object) Object {
+  if len(args) != 2 {
+    return newError("wrong number of arguments. got=%d, expected=2", len(args))
+  }
+
+  hash, err := hashArg("delete", args[0])
+  if err != nil {
+    return err
+  }
+
+  key, err := hashKeyArg(args[1])
+  if err != nil {
+    return err
+  }
+
+  deleted := copyHash(hash)
+  deleted.Delete(key)
+
+  return deleted
+}
+
+// builtinMerge combines any number of hashes, a key in a later one replaces the same key in an earlier one.
+func builtinMerge(args ...Object) Object {
+  if len(args) == 0 {
+    return newError("wrong number of arguments. got=0, expected at least 1")
+  }
+
+  merged := NewHash()
+  for _, arg := range args {
+    hash, err := hashArg("merge", arg)
+    if err != nil {
+      return err
+    }
+
+    for _, pair := range hash.OrderedPairs() {
+      merged.Set(pair.Key.(Hashable).HashKey(), pair)
+    }
+  }
+
+  return merged
+}
+
+func builtinSize(args ...Object) Object {
+  if len(args) != 1 {
+    return newError("wrong number of arguments. got=%d, expected=1", len(args))
+  }
+
+  hash, err := hashArg("size", args[0])
+  if err != nil {
+    return err
+  }
+
+  return &Integer{Value: int64(len(hash.Pairs))}
+}
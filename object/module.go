@@ -0,0 +1,88 @@
+package object
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Module is what an import evaluates to: the bindings the imported file exports, looked up by indexing
+// the module with their name.
+type Module struct {
+	Name    string // the path as written in the import
+	Path    string // the resolved path the file was loaded from
+	Exports map[string]Object
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return "<module " + m.Name + ">" }
+
+// Modules tracks the modules imported by the evaluations sharing it. A file is evaluated the first time
+// it is imported, later imports of the same resolved path get the cached module.
+type Modules struct {
+	// SearchPaths are the directories tried, in order, for an import that isn't found relative to the
+	// directory of the importing file.
+	SearchPaths []string
+
+	loaded  map[string]*Module
+	loading []string // the paths being imported, outermost first
+}
+
+func NewModules(searchPaths ...string) *Modules {
+	return &Modules{SearchPaths: searchPaths, loaded: make(map[string]*Module)}
+}
+
+// Resolve finds the file an import of name refers to, trying dir, the directory of the importing file,
+// first and then the search paths. The path it returns is absolute, so it can key the cache.
+func (m *Modules) Resolve(name string, dir string) (string, bool) {
+	candidates := []string{name}
+	if !filepath.IsAbs(name) {
+		candidates = []string{filepath.Join(dir, name)}
+		for _, searchPath := range m.SearchPaths {
+			candidates = append(candidates, filepath.Join(searchPath, name))
+		}
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		if abs, err := filepath.Abs(candidate); err == nil {
+			return abs, true
+		}
+		return filepath.Clean(candidate), true
+	}
+
+	return "", false
+}
+
+// Loaded returns the module already imported from path.
+func (m *Modules) Loaded(path string) (*Module, bool) {
+	module, ok := m.loaded[path]
+	return module, ok
+}
+
+// Begin records that path is being imported. It returns an error instead if path is already being
+// imported further out, since evaluating it again would never finish.
+func (m *Modules) Begin(path string) *Error {
+	for i, loading := range m.loading {
+		if loading == path {
+			cycle := append(append([]string{}, m.loading[i:]...), path)
+			return newError("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	m.loading = append(m.loading, path)
+	return nil
+}
+
+// End records that importing path finished, caching module unless the import failed and it is nil.
+func (m *Modules) End(path string, module *Module) {
+	m.loading = m.loading[:len(m.loading)-1]
+
+	if module != nil {
+		m.loaded[path] = module
+	}
+}
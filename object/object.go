@@ -4,7 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"math/big"
 	"monkey/ast"
+	"monkey/code"
+	"monkey/token"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +18,7 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ 			= "INTEGER"
+	FLOAT_OBJ 				= "FLOAT"
 	BOOLEAN_OBJ 			= "BOOLEAN"
 	NULL_OBJ 					= "NULL"
 	RETURN_VALUE_OBJ 	=	"RETURN"
@@ -21,6 +28,16 @@ const (
   BUILTIN_OBJ       = "BUILTIN"
 	ARRAY_OBJ				 	= "ARRAY"
 	HASH_OBJ					= "HASH"
+
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+
+	QUOTE_OBJ = "QUOTE"
+	MACRO_OBJ = "MACRO"
+
+	BREAK_OBJ    = "BREAK"
+	CONTINUE_OBJ = "CONTINUE"
+
+	MODULE_OBJ = "MODULE"
 )
 
 type Object interface {
@@ -44,6 +61,59 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// BigInteger is an integer too large for an int64, the evaluator switches to it when arithmetic
+// overflows. It reports itself as an INTEGER, scripts can't tell the two apart. Create one with
+// IntegerFromBig so that an integer that fits in an int64 is always an *Integer: the engines rely
+// on that when they compare integers or use them as hash keys.
+type BigInteger struct {
+	Value *big.Int
+}
+
+func (bi *BigInteger) Inspect() string {
+	return bi.Value.String()
+}
+
+func (bi *BigInteger) Type() ObjectType {
+	return INTEGER_OBJ
+}
+
+// HashKey keeps the exact digits of the value, a hash of them could collide with an Integer key.
+func (bi *BigInteger) HashKey() HashKey {
+	return HashKey{Type: bi.Type(), Big: bi.Value.String()}
+}
+
+// IntegerFromBig returns v as an *Integer when it fits in an int64 and as a *BigInteger otherwise.
+func IntegerFromBig(v *big.Int) Object {
+	if v.IsInt64() {
+		return &Integer{Value: v.Int64()}
+	}
+
+	return &BigInteger{Value: v}
+}
+
+type Float struct {
+	Value float64
+}
+
+// Inspect prints the shortest representation that reads back as the same float, always with a
+// decimal point or an exponent so 2.0 can't be mistaken for the integer 2.
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if strings.ContainsAny(s, ".eIN") {
+		return s
+	}
+
+	return s + ".0"
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+func (f *Float) HashKey() HashKey {
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
 type String struct {
 	Value string
 }
@@ -88,6 +158,27 @@ func (b *Boolean) HashKey() HashKey {
 
 type Null struct {}
 
+// TRUE, FALSE and NULL are the only instances the engines create, they compare booleans and null
+// by identity, so anything handing values to them has to use these too.
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+	NULL  = &Null{}
+)
+
+// IsTruthy reports whether obj counts as true in a condition, everything but null and false does.
+func IsTruthy(obj Object) bool {
+	return obj != NULL && obj != FALSE
+}
+
+// NativeBool returns TRUE or FALSE for value.
+func NativeBool(value bool) *Boolean {
+	if value {
+		return TRUE
+	}
+	return FALSE
+}
+
 func (n *Null) Inspect() string {
 	return "null"
 }
@@ -108,11 +199,122 @@ func (rv *ReturnValue) Type() ObjectType {
 	return RETURN_VALUE_OBJ
 }
 
+// Break and Continue signal a break or continue statement to the loop around it. Like a ReturnValue they
+// stop every block they pass through, until the loop consumes them.
+type Break struct{}
+
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+
+type Continue struct{}
+
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+
 type Error struct {
 	Message string
+	Pos     token.Position // the node that raised the error, the zero Position if it is unknown
+	Stack   []CallFrame    // the function calls the error unwound through, innermost first
+}
+
+// CallFrame is one function call in an error's traceback.
+type CallFrame struct {
+	Function string         // the name the function was bound to, or "<anonymous>"
+	Pos      token.Position // the call site
+	Args     []Object
+}
+
+func (cf CallFrame) String() string {
+	args := []string{}
+	for _, a := range cf.Args {
+		if str, ok := a.(*String); ok {
+			args = append(args, fmt.Sprintf("%q", str.Value)) // quoted so "1" and 1 can be told apart
+		} else {
+			args = append(args, a.Inspect())
+		}
+	}
+
+	return fmt.Sprintf("%s: in %s(%s)", cf.Pos, cf.Function, strings.Join(args, ", "))
+}
+
+// tracebackEnds is how many lines of a traceback are kept at each end, the calls in between a deep
+// recursion went through are left out.
+const tracebackEnds = 10
+
+// Traceback lists the calls that led to the error with the most recent call last, like Python does.
+// It is empty when the error was raised outside of any function.
+func (e *Error) Traceback() string {
+	if len(e.Stack) == 0 {
+		return ""
+	}
+
+	type tracebackLine struct {
+		text   string
+		frames int // how many frames of the stack the line stands for
+	}
+
+	// Runaway recursion leaves thousands of identical frames, those are folded into one line
+	lines := []tracebackLine{}
+	previous, repeated := "", 0
+	flush := func() {
+		if repeated > 0 {
+			text := fmt.Sprintf("[previous line repeated %d more times]", repeated)
+			lines = append(lines, tracebackLine{text: text, frames: repeated})
+		}
+	}
+
+	for i := len(e.Stack) - 1; i >= 0; i-- {
+		line := e.Stack[i].String()
+		if line == previous {
+			repeated++
+			continue
+		}
+
+		flush()
+		lines = append(lines, tracebackLine{text: line, frames: 1})
+		previous, repeated = line, 0
+	}
+	flush()
+
+	// Recursion through calls that differ, say in their arguments, doesn't fold, only its outermost and
+	// innermost calls are shown
+	if len(lines) > 2*tracebackEnds+1 {
+		hidden := 0
+		for _, line := range lines[tracebackEnds : len(lines)-tracebackEnds] {
+			hidden += line.frames
+		}
+
+		skipped := tracebackLine{text: fmt.Sprintf("... %d more frames", hidden), frames: hidden}
+		kept := append([]tracebackLine{}, lines[:tracebackEnds]...)
+		kept = append(kept, skipped)
+		lines = append(kept, lines[len(lines)-tracebackEnds:]...)
+	}
+
+	var out bytes.Buffer
+
+	out.WriteString("Traceback (most recent call last):\n")
+	for _, line := range lines {
+		out.WriteString("  " + line.text + "\n")
+	}
+
+	return out.String()
 }
 
 func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + e.Message
+	}
+
 	return "ERROR: " + e.Message
 }
 
@@ -122,8 +324,11 @@ func (e *Error) Type() ObjectType {
 
 type Function struct {
 	Parameters []*ast.Identifier
+	Defaults   []ast.Expression // default values, evaluated on each call that leaves the parameter out
+	Rest       *ast.Identifier  // the ...rest parameter, nil if there is none
 	Body 			 *ast.BlockStatement
 	Env 			 *Environment
+	Name 			 string // the name the function literal was bound to, empty for anonymous functions
 }
 
 func (f *Function) Type() ObjectType {
@@ -133,13 +338,12 @@ func (f *Function) Type() ObjectType {
 func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
-	params := []string{}
-
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
-	}
+	params := ast.ParametersString(f.Parameters, f.Defaults, f.Rest)
 
 	out.WriteString("fn")
+	if f.Name != "" {
+		out.WriteString("<" + f.Name + ">")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")")
@@ -148,10 +352,42 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
+// ArityError returns the error for calling f with n arguments, or nil if f accepts n arguments. Only
+// parameters without a default need an argument, and a rest parameter takes any number of extra ones.
+func (f *Function) ArityError(n int) *Error {
+	required := len(f.Parameters)
+	for i, d := range f.Defaults {
+		if d != nil {
+			required = i
+			break
+		}
+	}
+
+	switch {
+	case f.Rest != nil && n < required:
+		return newError("wrong number of arguments: want=at least %d, got=%d", required, n)
+	case f.Rest == nil && required < len(f.Parameters) && (n < required || n > len(f.Parameters)):
+		return newError("wrong number of arguments: want=%d to %d, got=%d", required, len(f.Parameters), n)
+	case f.Rest == nil && required == len(f.Parameters) && n != required:
+		return newError("wrong number of arguments: want=%d, got=%d", required, n)
+	}
+
+	return nil
+}
+
 type BuiltinFunction func(args ...Object) Object
 
+// Caller calls fn, a Monkey function or a builtin, with args. The engine running a builtin hands it one,
+// so builtins like map can call the functions they are passed.
+type Caller func(fn Object, args ...Object) Object
+
+type CallingBuiltinFunction func(call Caller, args ...Object) Object
+
+// A Builtin has either Fn or, when it calls back into Monkey, CallingFn set. Only the evaluator can run
+// the latter, the vm has no way to call a function from inside a builtin.
 type Builtin struct {
   Fn BuiltinFunction
+  CallingFn CallingBuiltinFunction
 }
 
 func (b *Builtin) Type() ObjectType {
@@ -162,6 +398,37 @@ func (b *Builtin) Inspect() string {
   return "builtin function"
 }
 
+// CompiledFunction is the bytecode for a function literal, it only ever lives in the compiler's constant pool.
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	NumLocals     int
+	NumParameters int
+	Positions     map[int]token.Position // source position of each instruction, keyed by its offset
+}
+
+func (cf *CompiledFunction) Type() ObjectType {
+	return COMPILED_FUNCTION_OBJ
+}
+
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Closure is what the VM hands to Monkey code for a function literal, so it reports itself as a FUNCTION
+// to keep error messages the same across both engines.
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
+
+func (c *Closure) Type() ObjectType {
+	return FUNCTION_OBJ
+}
+
+func (c *Closure) Inspect() string {
+	return fmt.Sprintf("Closure[%p]", c)
+}
+
 type Array struct {
 	Elements []Object
 }
@@ -189,6 +456,7 @@ func (ao *Array) Inspect() string {
 type HashKey struct {
 	Type ObjectType
 	Value uint64
+	Big   string // the decimal digits of a BigInteger key, which doesn't fit in Value
 }
 
 type HashPair struct {
@@ -196,8 +464,15 @@ type HashPair struct {
 	Value Object
 }
 
+// Hash keeps its pairs in the order their keys were first set, which is the order it prints and is
+// iterated in. Pairs is for looking keys up, changes have to go through Set and Delete to keep the order.
 type Hash struct {
 	Pairs map[HashKey]HashPair
+	keys  []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
 }
 
 func (h *Hash) Type() ObjectType {
@@ -209,7 +484,7 @@ func (h *Hash) Inspect() string {
 
 	pairs := []string{}
 
-	for _, pair := range h.Pairs {
+	for _, pair := range h.OrderedPairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
@@ -220,6 +495,113 @@ func (h *Hash) Inspect() string {
 	return out.String()
 }
 
+// Set stores pair under key. A key that is already there keeps its place and only gets the new pair.
+func (h *Hash) Set(key HashKey, pair HashPair) {
+	if h.Pairs == nil {
+		h.Pairs = make(map[HashKey]HashPair)
+	}
+
+	if _, ok := h.Pairs[key]; !ok {
+		h.keys = append(h.keys, key)
+	}
+	h.Pairs[key] = pair
+}
+
+func (h *Hash) Delete(key HashKey) {
+	if _, ok := h.Pairs[key]; !ok {
+		return
+	}
+
+	delete(h.Pairs, key)
+	for i, k := range h.keys {
+		if k == key {
+			h.keys = append(h.keys[:i], h.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// OrderedPairs returns the pairs in the order their keys were set. Pairs put into the map directly, by
+// a Hash built as a Go literal, come after those, sorted by key.
+func (h *Hash) OrderedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, key := range h.keys {
+		if pair, ok := h.Pairs[key]; ok {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	if len(pairs) == len(h.Pairs) {
+		return pairs
+	}
+
+	ordered := make(map[HashKey]bool, len(h.keys))
+	for _, key := range h.keys {
+		ordered[key] = true
+	}
+
+	rest := []HashPair{}
+	for key, pair := range h.Pairs {
+		if !ordered[key] {
+			rest = append(rest, pair)
+		}
+	}
+	sortPairs(rest)
+
+	return append(pairs, rest...)
+}
+
+// sortPairs orders pairs by their keys, for pairs that come from somewhere without an order of its own.
+func sortPairs(pairs []HashPair) {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Key.Type() != pairs[j].Key.Type() {
+			return pairs[i].Key.Type() < pairs[j].Key.Type()
+		}
+		return pairs[i].Key.Inspect() < pairs[j].Key.Inspect()
+	})
+}
+
 type Hashable interface {
 	HashKey() HashKey
-}
\ No newline at end of file
+}
+
+// Quote holds an unevaluated piece of code, it is what quote() returns and what a macro has to return.
+type Quote struct {
+	Node ast.Node
+}
+
+func (q *Quote) Type() ObjectType {
+	return QUOTE_OBJ
+}
+
+func (q *Quote) Inspect() string {
+	return "QUOTE(" + q.Node.String() + ")"
+}
+
+type Macro struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (m *Macro) Type() ObjectType {
+	return MACRO_OBJ
+}
+
+func (m *Macro) Inspect() string {
+	var out bytes.Buffer
+
+	params := []string{}
+
+	for _, p := range m.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("macro")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+	out.WriteString(m.Body.String())
+
+	return out.String()
+}
@@ -1,6 +1,11 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"math/big"
+	"monkey/ast"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -19,4 +24,91 @@ func TestStringHashKey(t *testing.T) {
 	if hello1.HashKey() == diff1.HashKey() {
 		t.Errorf("strings with different content have same hash keys")
 	}
-}
\ No newline at end of file
+}
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{2, "2.0"},
+		{-0.5, "-0.5"},
+		{0.1, "0.1"},
+		{1e21, "1e+21"},
+		{1.5e-7, "1.5e-07"},
+		{math.Inf(1), "+Inf"},
+		{math.NaN(), "NaN"},
+	}
+
+	for _, tt := range tests {
+		f := &Float{Value: tt.value}
+		if f.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect. want=%q, got=%q", tt.expected, f.Inspect())
+		}
+	}
+}
+
+func TestBigIntegerHashKey(t *testing.T) {
+	big1 := IntegerFromBig(new(big.Int).Lsh(big.NewInt(1), 70)).(*BigInteger)
+	big2 := IntegerFromBig(new(big.Int).Lsh(big.NewInt(1), 70)).(*BigInteger)
+
+	if big1.HashKey() != big2.HashKey() {
+		t.Errorf("big integers with same value have different hash keys")
+	}
+
+	// 2^64 used to hash to the same key as this Integer
+	big3, _ := new(big.Int).SetString("18446744073709551616", 10)
+	small := &Integer{Value: -1300789964862373523}
+	if IntegerFromBig(big3).(*BigInteger).HashKey() == small.HashKey() {
+		t.Errorf("big integer %s has the same hash key as integer %d", big3, small.Value)
+	}
+
+	if _, ok := IntegerFromBig(big.NewInt(42)).(*Integer); !ok {
+		t.Errorf("IntegerFromBig didn't return an Integer for a value that fits in an int64")
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	hash := NewHash()
+	for _, key := range []string{"c", "a", "b"} {
+		str := &String{Value: key}
+		hash.Set(str.HashKey(), HashPair{Key: str, Value: &Integer{Value: 1}})
+	}
+
+	a := &String{Value: "a"}
+	hash.Set(a.HashKey(), HashPair{Key: a, Value: &Integer{Value: 2}})
+	if hash.Inspect() != "{c: 1, a: 2, b: 1}" {
+		t.Errorf("setting a key again moved it. got=%s", hash.Inspect())
+	}
+
+	hash.Delete(a.HashKey())
+	hash.Set(a.HashKey(), HashPair{Key: a, Value: &Integer{Value: 3}})
+	if hash.Inspect() != "{c: 1, b: 1, a: 3}" {
+		t.Errorf("a deleted key didn't go to the end when set again. got=%s", hash.Inspect())
+	}
+
+	// pairs that didn't go through Set come after the others, sorted
+	literal := &Hash{Pairs: map[HashKey]HashPair{
+		(&String{Value: "y"}).HashKey(): {Key: &String{Value: "y"}, Value: TRUE},
+		(&String{Value: "x"}).HashKey(): {Key: &String{Value: "x"}, Value: TRUE},
+	}}
+	z := &String{Value: "z"}
+	literal.Set(z.HashKey(), HashPair{Key: z, Value: FALSE})
+	if literal.Inspect() != "{z: false, x: true, y: true}" {
+		t.Errorf("wrong order for a hash built as a literal. got=%s", literal.Inspect())
+	}
+}
+
+func TestFunctionInspect(t *testing.T) {
+	body := &ast.BlockStatement{Statements: []ast.Statement{}}
+	params := []*ast.Identifier{{Value: "x"}}
+
+	named := &Function{Parameters: params, Body: body, Name: "double"}
+	if named.Inspect() != "fn<double>(x)" {
+		t.Errorf("wrong inspect for a named function. got=%q", named.Inspect())
+	}
+
+	anonymous := &Function{Parameters: params, Body: body}
+	if anonymous.Inspect() != "fn(x)" {
+		t.Errorf("wrong inspect for an anonymous function. got=%q", anonymous.Inspect())
+	}
+}
@@ -0,0 +1,252 @@
+package object
+
+import (
+  "strings"
+  "unicode/utf8"
+)
+
+// maxRepeatLength is the longest string, in bytes, strings.repeat builds.
+const maxRepeatLength = 1 << 30
+
+// Strings is the strings namespace, its functions are looked up by name: strings["split"]("a,b", ",").
+// Positions and lengths count characters, the same ones a for loop over a string walks.
+var Strings = &Module{
+  Name: "strings",
+  Exports: map[string]Object{
+    "split": &Builtin{Fn: stringsSplit},
+    "join": &Builtin{Fn: stringsJoin},
+    "trim": &Builtin{Fn: stringsTrim},
+    "replace": &Builtin{Fn: stringsReplace},
+    "contains": &Builtin{Fn: stringsContains},
+    "starts_with": &Builtin{Fn: stringsStartsWith},
+    "ends_with": &Builtin{Fn: stringsEndsWith},
+    "index_of": &Builtin{Fn: stringsIndexOf},
+    "upper": &Builtin{Fn: stringsUpper},
+    "lower": &Builtin{Fn: stringsLower},
+    "repeat": &Builtin{Fn: stringsRepeat},
+    "substr": &Builtin{Fn: stringsSubstr},
+  },
+}
+
+// stringArgs checks that args are count strings and returns their values.
+func stringArgs(name string, args []Object, count int) ([]string, *Error) {
+  if len(args) != count {
+    return nil, newError("wrong number of arguments. got=%d, expected=%d", len(args), count)
+  }
+
+  values := make([]string, count)
+  for i, arg := range args {
+    str, ok := arg.(*String)
+    if !ok {
+      return nil, newError("argument to `strings.%s` must be STRING, got %s", name, arg.Type())
+    }
+    values[i] = str.Value
+  }
+
+  return values, nil
+}
+
+// intArg returns the value of an integer argument that fits in an int.
+func intArg(name string, arg Object) (int, *Error) {
+  switch arg := arg.(type) {
+  case *Integer:
+    if int64(int(arg.Value)) == arg.Value {
+      return int(arg.Value), nil
+    }
+    return 0, newError("argument to `strings.%s` out of range: %d", name, arg.Value)
+  case *BigInteger:
+    return 0, newError("argument to `strings.%s` out of range: %s", name, arg.Inspect())
+  default:
+    return 0, newError("argument to `strings.%s` must be INTEGER, got %s", name, arg.Type())
+  }
+}
+
+func stringsSplit(args ...Object) Object {
+  values, err := stringArgs("split", args, 2)
+  if err != nil {
+    return err
+  }
+
+  // an empty separator splits between every character
+  parts := strings.Split(values[0], values[1])
+  elements := make([]Object, len(parts))
+  for i, part := range parts {
+    elements[i] = &String{Value: part}
+  }
+
+  return &Array{Elements: elements}
+}
+
+func stringsJoin(args ...Object) Object {
+  if len(args) != 2 {
+    return newError("wrong number of arguments. got=%d, expected=2", len(args))
+  }
+
+  arr, ok := args[0].(*Array)
+  if !ok {
+    return newError("argument to `strings.join` must be ARRAY, got %s", args[0].Type())
+  }
+
+  sep, ok := args[1].(*String)
+  if !ok {
+    return newError("argument to `strings.join` must be STRING, got %s", args[1].Type())
+  }
+
+  parts := make([]string, len(arr.Elements))
+  for i, element := range arr.Elements {
+    str, ok := element.(*String)
+    if !ok {
+      return newError("`strings.join` can only join STRING elements, got %s", element.Type())
+    }
+    parts[i] = str.Value
+  }
+
+  return &String{Value: strings.Join(parts, sep.Value)}
+}
+
+func stringsTrim(args ...Object) Object {
+  values, err := stringArgs("trim", args, 1)
+  if err != nil {
+    return err
+  }
+
+  return &String{Value: strings.TrimSpace(values[0])}
+}
+
+func stringsReplace(args ...Object) Object {
+  values, err := stringArgs("replace", args, 3)
+  if err != nil {
+    return err
+  }
+
+  return &String{Value: strings.ReplaceAll(values[0], values[1], values[2])}
+}
+
+func stringsContains(args ...Object) Object {
+  values, err := stringArgs("contains", args, 2)
+  if err != nil {
+    return err
+  }
+
+  return NativeBool(strings.Contains(values[0], values[1]))
+}
+
+func stringsStartsWith(args ...Object) Object {
+  values, err := stringArgs("starts_with", args, 2)
+  if err != nil {
+    return err
+  }
+
+  return NativeBool(strings.HasPrefix(values[0], values[1]))
+}
+
+func stringsEndsWith(args ...Object) Object {
+  values, err := stringArgs("ends_with", args, 2)
+  if err != nil {
+    return err
+  }
+
+  return NativeBool(strings.HasSuffix(values[0], values[1]))
+}
+
+// stringsIndexOf returns the position of the first character of the first match, -1 when there is none.
+func stringsIndexOf(args ...Object) Object {
+  values, err := stringArgs("index_of", args, 2)
+  if err != nil {
+    return err
+  }
+
+  index := strings.Index(values[0], values[1])
+  if index < 0 {
+    return &Integer{Value: -1}
+  }
+
+  return &Integer{Value: int64(utf8.RuneCountInString(values[0][:index]))}
+}
+
+func stringsUpper(args ...Object) Object {
+  values, err := stringArgs("upper", args, 1)
+  if err != nil {
+    return err
+  }
+
+  return &String{Value: strings.ToUpper(values[0])}
+}
+
+func stringsLower(args ...Object) Object {
+  values, err := stringArgs("lower", args, 1)
+  if err != nil {
+    return err
+  }
+
+  return &String{Value: strings.ToLower(values[0])}
+}
+
+func stringsRepeat(args ...Object) Object {
+  if len(args) != 2 {
+    return newError("wrong number of arguments. got=%d, expected=2", len(args))
+  }
+
+  values, err := stringArgs("repeat", args[:1], 1)
+  if err != nil {
+    return err
+  }
+
+  count, err := intArg("repeat", args[1])
+  if err != nil {
+    return err
+  }
+
+  if count < 0 {
+    return newError("`strings.repeat` count must not be negative, got %d", count)
+  }
+
+  // checked by division, len(s) * count can itself overflow
+  if count > 0 && len(values[0]) > maxRepeatLength/count {
+    return newError("`strings.repeat` result too long: %d times %d bytes", count, len(values[0]))
+  }
+
+  return &String{Value: strings.Repeat(values[0], count)}
+}
+
+// stringsSubstr returns the characters of a string from start on, at most length of them when a length
+// is given.
+func stringsSubstr(args ...Object) Object {
+  if len(args) != 2 && len(args) != 3 {
+    return newError("wrong number of arguments. got=%d, expected=2 or 3", len(args))
+  }
+
+  values, err := stringArgs("substr", args[:1], 1)
+  if err != nil {
+    return err
+  }
+  runes := []rune(values[0])
+
+  start, err := intArg("substr", args[1])
+  if err != nil {
+    return err
+  }
+
+  if start < 0 || start > len(runes) {
+    return newError("`strings.substr` start out of range: %d", start)
+  }
+
+  end := len(runes)
+  if len(args) == 3 {
+    length, err := intArg("substr", args[2])
+    if err != nil {
+      return err
+    }
+
+    if length < 0 {
+      return newError("`strings.substr` length must not be negative, got %d", length)
+    }
+
+    // start+length could overflow, the length is compared with what is left instead
+    if length < end-start {
+      end = start + length
+    }
+  }
+
+  return &String{Value: string(runes[start:end])}
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+  "monkey/ast"
+  "monkey/token"
+)
+
+// ParseError describes a single syntax error, so tools can inspect it instead of scraping a message.
+type ParseError struct {
+  Pos      token.Position    // where the error was detected
+  Expected []token.TokenType // the token types that would have been valid here, empty if there is no single answer
+  Found    token.Token       // the token the parser actually saw
+  Message  string
+}
+
+func (e *ParseError) Error() string {
+  return e.Pos.String() + ": " + e.Message
+}
+
+// addError records err unless the parser is already recovering from an earlier error in the same statement,
+// the tokens it skips over would only produce follow-on errors that hide the real one.
+func (parser *Parser) addError(err *ParseError) {
+  if parser.recovering {
+    return
+  }
+
+  parser.errors = append(parser.errors, err)
+  parser.recovering = true
+}
+
+// synchronize skips the rest of a statement that failed to parse. It stops on the statement's ';', or
+// just before a '}' closing the enclosing block, EOF or a keyword that can only start a new statement,
+// so the caller's loop picks up parsing at the next statement.
+func (parser *Parser) synchronize() {
+  depth := 0
+
+  for !parser.currentTokenIs(token.EOF) {
+    switch parser.currentToken.Type {
+    case token.LBRACE:
+      depth++
+    case token.RBRACE:
+      if depth > 0 {
+        depth--
+      }
+    }
+
+    if depth == 0 && parser.atStatementBoundary() {
+      break
+    }
+
+    parser.nextToken()
+  }
+
+  parser.recovering = false
+}
+
+func (parser *Parser) atStatementBoundary() bool {
+  if parser.currentTokenIs(token.SEMICOLON) {
+    return true
+  }
+
+  switch parser.peekToken.Type {
+  case token.RBRACE, token.EOF, token.LET, token.RETURN, token.WHILE, token.FOR, token.BREAK, token.CONTINUE:
+    return true
+  }
+
+  return false
+}
+
+// parseIllegal reports the problem the lexer found with an ILLEGAL token, such as an unterminated string.
+func (parser *Parser) parseIllegal() ast.Expression {
+  tok := parser.currentToken
+  err := &ParseError{Pos: tok.Pos, Found: tok, Message: "illegal token " + tok.Literal}
+
+  for _, lexErr := range parser.lexer.Errors() {
+    if lexErr.Pos.Offset >= tok.Pos.Offset && lexErr.Pos.Offset < tok.Pos.Offset+len(tok.Literal) {
+      err.Pos = lexErr.Pos
+      err.Message = lexErr.Message
+      break
+    }
+  }
+
+  parser.addError(err)
+  return nil
+}
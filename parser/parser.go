@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 	"monkey/ast"
 	"monkey/lexer"
 	"monkey/token"
@@ -16,13 +17,16 @@ type (
 const ( // ORDER OF PRECENDENCE FOR OPERANDS
   _ int = iota // gives the values below incrementing values, 0, 1, 2, etc.
   LOWEST // (1)
-  EQUALS // == (2)
-  LESSGREATER // > or < (3)
-  SUM // + (4)
-  PRODUCT // * (5)
-  PREFIX // -X OR !X (6)
-  CALL // myFn(X) (7)
-  INDEX // array[index] (8)
+  ASSIGN // x = y, x += y (2)
+  OR // || (3)
+  AND // && (4)
+  EQUALS // == (5)
+  LESSGREATER // > or < (6)
+  SUM // + (7)
+  PRODUCT // * (8)
+  PREFIX // -X OR !X (9)
+  CALL // myFn(X) (10)
+  INDEX // array[index] (11)
 ) // e.g. PDOCUT (*) has higher order precedence than EQUALS (==)
 
 var precedences = map[token.TokenType]int {
@@ -30,19 +34,33 @@ var precedences = map[token.TokenType]int {
   token.NOT_EQ:       EQUALS,
   token.LT:           LESSGREATER,
   token.GT:           LESSGREATER,
+  token.LT_EQ:        LESSGREATER,
+  token.GT_EQ:        LESSGREATER,
   token.PLUS:         SUM,
   token.MINUS:        SUM,
   token.SLASH:        PRODUCT,
   token.ASTERISK:     PRODUCT,
+  token.PERCENT:      PRODUCT,
+  token.AND:          AND,
+  token.OR:           OR,
   token.LPAREN:       CALL,
   token.LBRACKET:     INDEX,
+  token.ASSIGN:          ASSIGN,
+  token.PLUS_ASSIGN:     ASSIGN,
+  token.MINUS_ASSIGN:    ASSIGN,
+  token.ASTERISK_ASSIGN: ASSIGN,
+  token.SLASH_ASSIGN:    ASSIGN,
 }
 
 type Parser struct {
   lexer *lexer.Lexer
   currentToken token.Token
   peekToken token.Token
-  errors []string
+  errors []*ParseError
+  recovering bool // set after an error until the parser has skipped to the next statement
+  loopDepth int // how many loops enclose the current token within the current function, for break and continue
+  blockDepth int // how many blocks enclose the current token, export is only allowed outside of all of them
+  comments []token.Comment // the comments read so far, in source order
   prefixParseFns map[token.TokenType]prefixParseFn
   infixParseFns map[token.TokenType]infixParseFn
 }
@@ -50,7 +68,7 @@ type Parser struct {
 func New(lexer *lexer.Lexer) *Parser {
   p := &Parser{
     lexer: lexer,
-    errors: []string{},
+    errors: []*ParseError{},
   }
 
   // Read two tokens, so currentToken and peekToken are both set
@@ -60,6 +78,8 @@ func New(lexer *lexer.Lexer) *Parser {
   p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
   p.registerPrefix(token.IDENT, p.parseIdentifier)
   p.registerPrefix(token.INT, p.parseIntegerLiteral)
+  p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+  p.registerPrefix(token.ILLEGAL, p.parseIllegal)
   p.registerPrefix(token.BANG, p.parsePrefixExpression)
   p.registerPrefix(token.MINUS, p.parsePrefixExpression)
   p.registerPrefix(token.TRUE, p.parseBoolean)
@@ -70,6 +90,8 @@ func New(lexer *lexer.Lexer) *Parser {
   p.registerPrefix(token.STRING, p.parseStringLiteral)
   p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
   p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+  p.registerPrefix(token.MACRO, p.parseMacroLiteral)
+  p.registerPrefix(token.IMPORT, p.parseImportExpression)
 
   p.infixParseFns = make(map[token.TokenType]infixParseFn)
   p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -80,13 +102,23 @@ func New(lexer *lexer.Lexer) *Parser {
   p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
   p.registerInfix(token.LT, p.parseInfixExpression)
   p.registerInfix(token.GT, p.parseInfixExpression)
+  p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+  p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+  p.registerInfix(token.PERCENT, p.parseInfixExpression)
+  p.registerInfix(token.AND, p.parseInfixExpression)
+  p.registerInfix(token.OR, p.parseInfixExpression)
   p.registerInfix(token.LPAREN, p.parseCallExpression)
   p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+  p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+  p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+  p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+  p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+  p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
 
   return p
 }
 
-func (parser *Parser) Errors() []string {
+func (parser *Parser) Errors() []*ParseError {
   return parser.errors
 }
 
@@ -95,35 +127,105 @@ func (parser *Parser) ParseProgram() *ast.Program {
   program.Statements = []ast.Statement{}
 
   for !parser.currentTokenIs(token.EOF) {
-    statement := parser.parseStatement()
+    statement := parser.parseStatementOrRecover()
     if statement != nil {
       program.Statements = append(program.Statements, statement)
     }
     parser.nextToken()
   }
 
+  program.Comments = parser.comments
   return program
 }
 
+// parseStatementOrRecover drops a statement that produced errors and skips to the end of it,
+// so one bad token doesn't stop the rest of the input from being checked.
+func (parser *Parser) parseStatementOrRecover() ast.Statement {
+  errorCount := len(parser.errors)
+  statement := parser.parseStatement()
+
+  if len(parser.errors) > errorCount || parser.recovering {
+    parser.synchronize()
+    return nil
+  }
+
+  return statement
+}
+
 func (parser *Parser) parseStatement() ast.Statement {
   switch parser.currentToken.Type {
   case token.LET:
     return parser.parseLetStatement()
+  case token.EXPORT:
+    return parser.parseExportStatement()
   case token.RETURN:
     return parser.parseReturnStatement()
+  case token.WHILE:
+    return parser.parseWhileStatement()
+  case token.FOR:
+    return parser.parseForStatement()
+  case token.BREAK:
+    return parser.parseLoopControlStatement(&ast.BreakStatement{Token: parser.currentToken})
+  case token.CONTINUE:
+    return parser.parseLoopControlStatement(&ast.ContinueStatement{Token: parser.currentToken})
   default:
     return parser.parseExpressionStatement()
   }
 }
 
 func (parser *Parser) parseLetStatement() *ast.LetStatement {
-  statement := &ast.LetStatement{Token: parser.currentToken}
+  statement := parser.parseLetClause()
+  if statement == nil {
+    return nil
+  }
+
+  for parser.peekTokenIs(token.SEMICOLON) {
+    parser.nextToken()
+  }
 
-  if !parser.expectPeek(token.IDENT) {
+  return statement
+}
+
+func (parser *Parser) parseExportStatement() *ast.LetStatement {
+  if parser.blockDepth > 0 {
+    parser.addError(&ParseError{
+      Pos: parser.currentToken.Pos,
+      Found: parser.currentToken,
+      Message: "export is only allowed at the top level",
+    })
+    return nil
+  }
+
+  if !parser.expectPeek(token.LET) {
     return nil
   }
 
-  statement.Name = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+  statement := parser.parseLetStatement()
+  if statement == nil {
+    return nil
+  }
+
+  statement.Exported = true
+  return statement
+}
+
+// parseLetClause parses a let statement up to, but not including, the semicolon after it.
+func (parser *Parser) parseLetClause() *ast.LetStatement {
+  statement := &ast.LetStatement{Token: parser.currentToken}
+
+  if parser.peekTokenIs(token.LBRACKET) || parser.peekTokenIs(token.LBRACE) {
+    parser.nextToken()
+    statement.Pattern = parser.parsePattern()
+    if statement.Pattern == nil {
+      return nil
+    }
+  } else {
+    if !parser.expectPeek(token.IDENT) {
+      return nil
+    }
+
+    statement.Name = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+  }
 
   if !parser.expectPeek(token.ASSIGN) {
     return nil
@@ -133,13 +235,105 @@ func (parser *Parser) parseLetStatement() *ast.LetStatement {
 
   statement.Value = parser.parseExpression(LOWEST)
 
-  for parser.peekTokenIs(token.SEMICOLON) {
-    parser.nextToken()
+  if fl, ok := statement.Value.(*ast.FunctionLiteral); ok && statement.Name != nil {
+    fl.Name = statement.Name.Value
   }
 
   return statement
 }
 
+// parsePattern parses what a destructuring let binds to: a name, an array pattern or a hash pattern.
+func (parser *Parser) parsePattern() ast.Expression {
+  switch parser.currentToken.Type {
+  case token.IDENT:
+    return &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+  case token.LBRACKET:
+    return parser.parseArrayPattern()
+  case token.LBRACE:
+    return parser.parseHashPattern()
+  default:
+    parser.addError(&ParseError{
+      Pos: parser.currentToken.Pos,
+      Expected: []token.TokenType{token.IDENT, token.LBRACKET, token.LBRACE},
+      Found: parser.currentToken,
+      Message: fmt.Sprintf("expected a name, [ or { to bind to, but received %s", parser.currentToken.Type),
+    })
+    return nil
+  }
+}
+
+func (parser *Parser) parseArrayPattern() ast.Expression {
+  pattern := &ast.ArrayPattern{Token: parser.currentToken}
+
+  for !parser.peekTokenIs(token.RBRACKET) {
+    parser.nextToken()
+
+    if parser.currentTokenIs(token.ELLIPSIS) {
+      if !parser.expectPeek(token.IDENT) {
+        return nil
+      }
+      pattern.Rest = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+      if parser.peekTokenIs(token.COMMA) {
+        parser.nextToken()
+        parser.addError(&ParseError{
+          Pos: parser.currentToken.Pos,
+          Found: parser.currentToken,
+          Message: fmt.Sprintf("rest element ...%s must be the last element", pattern.Rest.Value),
+        })
+        return nil
+      }
+      break
+    }
+
+    element := parser.parsePattern()
+    if element == nil {
+      return nil
+    }
+    pattern.Elements = append(pattern.Elements, element)
+
+    if !parser.peekTokenIs(token.RBRACKET) && !parser.expectPeek(token.COMMA) {
+      return nil
+    }
+  }
+
+  if !parser.expectPeek(token.RBRACKET) {
+    return nil
+  }
+
+  return pattern
+}
+
+func (parser *Parser) parseHashPattern() ast.Expression {
+  pattern := &ast.HashPattern{Token: parser.currentToken}
+
+  for !parser.peekTokenIs(token.RBRACE) {
+    parser.nextToken()
+    key := parser.parseExpression(LOWEST)
+    if key == nil || !parser.expectPeek(token.COLON) {
+      return nil
+    }
+
+    parser.nextToken()
+    value := parser.parsePattern()
+    if value == nil {
+      return nil
+    }
+
+    pattern.Keys = append(pattern.Keys, key)
+    pattern.Values = append(pattern.Values, value)
+
+    if !parser.peekTokenIs(token.RBRACE) && !parser.expectPeek(token.COMMA) {
+      return nil
+    }
+  }
+
+  if !parser.expectPeek(token.RBRACE) {
+    return nil
+  }
+
+  return pattern
+}
+
 func (parser *Parser) parseReturnStatement() *ast.ReturnStatement {
   statement := &ast.ReturnStatement{Token: parser.currentToken}
   parser.nextToken()
@@ -153,6 +347,161 @@ func (parser *Parser) parseReturnStatement() *ast.ReturnStatement {
   return statement
 }
 
+func (parser *Parser) parseWhileStatement() ast.Statement {
+  statement := &ast.WhileStatement{Token: parser.currentToken}
+
+  if !parser.expectPeek(token.LPAREN) {
+    return nil
+  }
+
+  parser.nextToken()
+  statement.Condition = parser.parseExpression(LOWEST)
+
+  if !parser.expectPeek(token.RPAREN) {
+    return nil
+  }
+
+  statement.Body = parser.parseLoopBody()
+  if statement.Body == nil {
+    return nil
+  }
+
+  return statement
+}
+
+// parseForStatement parses both kinds of for loop, telling them apart by whether the first name
+// in the parentheses is followed by 'in' or ','.
+func (parser *Parser) parseForStatement() ast.Statement {
+  forToken := parser.currentToken
+
+  if !parser.expectPeek(token.LPAREN) {
+    return nil
+  }
+
+  statement := &ast.ForStatement{Token: forToken}
+
+  if !parser.peekTokenIs(token.SEMICOLON) {
+    parser.nextToken()
+
+    if parser.currentTokenIs(token.IDENT) && (parser.peekTokenIs(token.IN) || parser.peekTokenIs(token.COMMA)) {
+      return parser.parseForInStatement(forToken)
+    }
+
+    statement.Init = parser.parseForClause()
+  }
+
+  if !parser.expectPeek(token.SEMICOLON) {
+    return nil
+  }
+
+  if !parser.peekTokenIs(token.SEMICOLON) {
+    parser.nextToken()
+    statement.Condition = parser.parseExpression(LOWEST)
+  }
+
+  if !parser.expectPeek(token.SEMICOLON) {
+    return nil
+  }
+
+  if !parser.peekTokenIs(token.RPAREN) {
+    parser.nextToken()
+    statement.Post = parser.parseForClause()
+  }
+
+  if !parser.expectPeek(token.RPAREN) {
+    return nil
+  }
+
+  statement.Body = parser.parseLoopBody()
+  if statement.Body == nil {
+    return nil
+  }
+
+  return statement
+}
+
+// parseForClause parses the init or post clause of a C-style for loop, a let statement or an expression.
+func (parser *Parser) parseForClause() ast.Statement {
+  if parser.currentTokenIs(token.LET) {
+    if let := parser.parseLetClause(); let != nil {
+      return let
+    }
+    return nil
+  }
+
+  return &ast.ExpressionStatement{Token: parser.currentToken, Expression: parser.parseExpression(LOWEST)}
+}
+
+// parseForInStatement continues parsing a for loop from the first name in its parentheses.
+func (parser *Parser) parseForInStatement(forToken token.Token) ast.Statement {
+  statement := &ast.ForInStatement{Token: forToken}
+  statement.Value = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+  if parser.peekTokenIs(token.COMMA) {
+    parser.nextToken()
+
+    if !parser.expectPeek(token.IDENT) {
+      return nil
+    }
+
+    statement.Key = statement.Value
+    statement.Value = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+  }
+
+  if !parser.expectPeek(token.IN) {
+    return nil
+  }
+
+  parser.nextToken()
+  statement.Iterable = parser.parseExpression(LOWEST)
+
+  if !parser.expectPeek(token.RPAREN) {
+    return nil
+  }
+
+  statement.Body = parser.parseLoopBody()
+  if statement.Body == nil {
+    return nil
+  }
+
+  return statement
+}
+
+// parseLoopBody parses the block of a loop, where break and continue are allowed, and the optional
+// semicolon after it.
+func (parser *Parser) parseLoopBody() *ast.BlockStatement {
+  if !parser.expectPeek(token.LBRACE) {
+    return nil
+  }
+
+  parser.loopDepth++
+  body := parser.parseBlockStatement()
+  parser.loopDepth--
+
+  if parser.peekTokenIs(token.SEMICOLON) {
+    parser.nextToken()
+  }
+
+  return body
+}
+
+func (parser *Parser) parseLoopControlStatement(statement ast.Statement) ast.Statement {
+  if parser.loopDepth == 0 {
+    parser.addError(&ParseError{
+      Pos: parser.currentToken.Pos,
+      Found: parser.currentToken,
+      Message: fmt.Sprintf("%s outside of a loop", parser.currentToken.Literal),
+    })
+    return nil
+  }
+
+  if parser.peekTokenIs(token.SEMICOLON) {
+    parser.nextToken()
+  }
+
+  return statement
+}
+
 func (parser *Parser) parseExpressionStatement() *ast.ExpressionStatement {
   statement := &ast.ExpressionStatement{Token: parser.currentToken}
   statement.Expression = parser.parseExpression(LOWEST)
@@ -194,11 +543,36 @@ func (parser *Parser) parseExpression(precendence int) ast.Expression {
 func (parser *Parser) parseIntegerLiteral() ast.Expression {
   literal := &ast.IntegerLiteral{Token: parser.currentToken}
 
-  value, err := strconv.ParseInt(parser.currentToken.Literal, 0, 64)
+  value, ok := new(big.Int).SetString(parser.currentToken.Literal, 0)
+
+  if !ok {
+    parser.addError(&ParseError{
+      Pos: parser.currentToken.Pos,
+      Found: parser.currentToken,
+      Message: fmt.Sprintf("Failed to parse %q as an integer", parser.currentToken.Literal),
+    })
+    return nil
+  }
+
+  if value.IsInt64() {
+    literal.Value = value.Int64()
+  } else {
+    literal.Big = value
+  }
+  return literal
+}
+
+func (parser *Parser) parseFloatLiteral() ast.Expression {
+  literal := &ast.FloatLiteral{Token: parser.currentToken}
+
+  value, err := strconv.ParseFloat(parser.currentToken.Literal, 64)
 
   if err != nil {
-    msg := fmt.Sprintf("Failed to parse %q as an integer", parser.currentToken.Literal)
-    parser.errors = append(parser.errors, msg)
+    parser.addError(&ParseError{
+      Pos: parser.currentToken.Pos,
+      Found: parser.currentToken,
+      Message: fmt.Sprintf("Failed to parse %q as a float", parser.currentToken.Literal),
+    })
     return nil
   }
 
@@ -210,6 +584,17 @@ func (parser *Parser) parseStringLiteral() ast.Expression {
   return &ast.StringLiteral{Token: parser.currentToken, Value: parser.currentToken.Literal}
 }
 
+func (parser *Parser) parseImportExpression() ast.Expression {
+  expression := &ast.ImportExpression{Token: parser.currentToken}
+
+  if !parser.expectPeek(token.STRING) {
+    return nil
+  }
+
+  expression.Path = &ast.StringLiteral{Token: parser.currentToken, Value: parser.currentToken.Literal}
+  return expression
+}
+
 func (parser *Parser) parseIdentifier() ast.Expression {
   return &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
 }
@@ -245,6 +630,28 @@ func (parser *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 
 }
 
+// parseAssignExpression parses the right-hand side with a lower precedence than its own,
+// so that a = b = c assigns c to both.
+func (parser *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+  expression := &ast.AssignExpression{Token: parser.currentToken, Target: target, Operator: parser.currentToken.Literal}
+
+  switch target.(type) {
+  case *ast.Identifier, *ast.IndexExpression:
+  default:
+    parser.addError(&ParseError{
+      Pos: target.Pos(),
+      Found: parser.currentToken,
+      Message: fmt.Sprintf("cannot assign to %s", target.String()),
+    })
+    return nil
+  }
+
+  parser.nextToken()
+  expression.Value = parser.parseExpression(ASSIGN - 1)
+
+  return expression
+}
+
 func (parser *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
   expression := &ast.IndexExpression{
     Token: parser.currentToken, 
@@ -342,13 +749,13 @@ func (parser *Parser) parseFunctionLiteral() ast.Expression {
     return nil
   }
   
-  literal.Parameters = parser.parseFunctionParameters()
+  literal.Parameters, literal.Defaults, literal.Rest = parser.parseFunctionParameters()
 
   if !parser.expectPeek(token.LBRACE) {
     return nil
   }
 
-  literal.Body = parser.parseBlockStatement()
+  literal.Body = parser.parseFunctionBody()
 
   return literal
 }
@@ -358,43 +765,105 @@ func (parser *Parser) parseBlockStatement() *ast.BlockStatement {
   block.Statements = []ast.Statement{}
 
   parser.nextToken()
+  parser.blockDepth++
 
   for !parser.currentTokenIs(token.RBRACE) && !parser.currentTokenIs(token.EOF) {
-    statement := parser.parseStatement()
+    statement := parser.parseStatementOrRecover()
     if statement != nil {
       block.Statements = append(block.Statements, statement)
     }
     parser.nextToken()
   }
 
+  parser.blockDepth--
+
   return block
 }
 
-func (parser *Parser) parseFunctionParameters() []*ast.Identifier {
-  identifiers := []*ast.Identifier{}
+// parseFunctionBody parses the block of a function or macro literal, a loop around the literal
+// doesn't make break and continue valid inside it.
+func (parser *Parser) parseFunctionBody() *ast.BlockStatement {
+  loopDepth := parser.loopDepth
+  parser.loopDepth = 0
+  body := parser.parseBlockStatement()
+  parser.loopDepth = loopDepth
+
+  return body
+}
+
+// parseFunctionParameters parses a parameter list: plain names, then names with a default value such
+// as b = 2, then optionally a rest parameter such as ...others. Defaults is nil when no parameter has one.
+func (parser *Parser) parseFunctionParameters() (identifiers []*ast.Identifier, defaults []ast.Expression, rest *ast.Identifier) {
+  identifiers = []*ast.Identifier{}
 
   if parser.peekTokenIs(token.RPAREN) {
     parser.nextToken()
-    return identifiers
+    return identifiers, nil, nil
   }
 
-  parser.nextToken()
+  for {
+    parser.nextToken()
 
-  identifier := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
-  identifiers = append(identifiers, identifier)
+    if rest != nil {
+      parser.addError(&ParseError{
+        Pos: parser.currentToken.Pos,
+        Found: parser.currentToken,
+        Message: fmt.Sprintf("rest parameter ...%s must be the last parameter", rest.Value),
+      })
+      return nil, nil, nil
+    }
 
-  for parser.peekTokenIs(token.COMMA) {
-    parser.nextToken()
+    if parser.currentTokenIs(token.ELLIPSIS) {
+      if !parser.expectPeek(token.IDENT) {
+        return nil, nil, nil
+      }
+      rest = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+    } else {
+      if parser.currentTokenIs(token.ILLEGAL) {
+        parser.parseIllegal()
+        return nil, nil, nil
+      }
+      if !parser.currentTokenIs(token.IDENT) {
+        parser.addError(&ParseError{
+          Pos: parser.currentToken.Pos,
+          Expected: []token.TokenType{token.IDENT},
+          Found: parser.currentToken,
+          Message: fmt.Sprintf("expected a parameter name, but received %s", parser.currentToken.Type),
+        })
+        return nil, nil, nil
+      }
+
+      identifier := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+      identifiers = append(identifiers, identifier)
+
+      if parser.peekTokenIs(token.ASSIGN) {
+        parser.nextToken()
+        parser.nextToken()
+        for len(defaults) < len(identifiers)-1 {
+          defaults = append(defaults, nil)
+        }
+        defaults = append(defaults, parser.parseExpression(LOWEST))
+      } else if defaults != nil {
+        parser.addError(&ParseError{
+          Pos: identifier.Pos(),
+          Found: parser.currentToken,
+          Message: fmt.Sprintf("parameter %s without a default follows one with a default", identifier.Value),
+        })
+        return nil, nil, nil
+      }
+    }
+
+    if !parser.peekTokenIs(token.COMMA) {
+      break
+    }
     parser.nextToken()
-    identifier := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
-    identifiers = append(identifiers, identifier)
   }
 
   if !parser.expectPeek(token.RPAREN) {
-    return nil
+    return nil, nil, nil
   }
 
-  return identifiers
+  return identifiers, defaults, rest
 }
 
 func (parser *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
@@ -422,8 +891,11 @@ func (parser *Parser) currentPrecendence() int {
 }
 
 func (parser *Parser) noPrefixParseFnError(t token.TokenType) {
-  msg := fmt.Sprintf("No prefix parser function found for %s", t)
-  parser.errors = append(parser.errors, msg)
+  parser.addError(&ParseError{
+    Pos: parser.currentToken.Pos,
+    Found: parser.currentToken,
+    Message: fmt.Sprintf("No prefix parser function found for %s", t),
+  })
 }
 
 func (parser *Parser) currentTokenIs(tokenType token.TokenType) bool {
@@ -445,13 +917,18 @@ func (parser *Parser) expectPeek(tokenType token.TokenType) bool {
 }
 
 func (parser *Parser) peekError(tokenType token.TokenType) {
-  msg := fmt.Sprintf("expected next token to be %s, but received %s", tokenType, parser.peekToken.Type)
-  parser.errors = append(parser.errors, msg)
+  parser.addError(&ParseError{
+    Pos: parser.peekToken.Pos,
+    Expected: []token.TokenType{tokenType},
+    Found: parser.peekToken,
+    Message: fmt.Sprintf("expected next token to be %s, but received %s", tokenType, parser.peekToken.Type),
+  })
 }
 
 func (parser *Parser) nextToken() {
   parser.currentToken = parser.peekToken
   parser.peekToken = parser.lexer.NextToken()
+  parser.comments = append(parser.comments, parser.peekToken.Leading...)
 }
 
 func (parser *Parser) parseBoolean() ast.Expression {
@@ -491,7 +968,7 @@ func (parser *Parser) parseExpressionList(end token.TokenType) []ast.Expression
 
 func (parser *Parser) parseHashLiteral() ast.Expression {
   hash := &ast.HashLiteral{Token: parser.currentToken}
-  hash.Pairs = make(map[ast.Expression]ast.Expression)
+  hash.Pairs = []ast.HashPair{}
 
   for !parser.peekTokenIs(token.RBRACE) {
     parser.nextToken()
@@ -504,7 +981,7 @@ func (parser *Parser) parseHashLiteral() ast.Expression {
     parser.nextToken()
     value := parser.parseExpression(LOWEST)
 
-    hash.Pairs[key] = value
+    hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
 
     if !parser.peekTokenIs(token.RBRACE) && !parser.expectPeek(token.COMMA) {
       return nil
@@ -516,4 +993,33 @@ func (parser *Parser) parseHashLiteral() ast.Expression {
   }
 
   return hash
-}
\ No newline at end of file
+}
+
+func (parser *Parser) parseMacroLiteral() ast.Expression {
+  literal := &ast.MacroLiteral{Token: parser.currentToken}
+
+  if !parser.expectPeek(token.LPAREN) {
+    return nil
+  }
+
+  var defaults []ast.Expression
+  var rest *ast.Identifier
+  literal.Parameters, defaults, rest = parser.parseFunctionParameters()
+
+  if defaults != nil || rest != nil {
+    parser.addError(&ParseError{
+      Pos: literal.Pos(),
+      Found: literal.Token,
+      Message: "macro parameters can't have default values or a rest parameter",
+    })
+    return nil
+  }
+
+  if !parser.expectPeek(token.LBRACE) {
+    return nil
+  }
+
+  literal.Body = parser.parseFunctionBody()
+
+  return literal
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"monkey/ast"
 	"monkey/lexer"
+	"monkey/token"
 	"testing"
 )
 
@@ -304,6 +305,49 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	l := lexer.New("99999999999999999999;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Big == nil || literal.Big.String() != "99999999999999999999" {
+		t.Errorf("literal.Big not 99999999999999999999. got=%v", literal.Big)
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.25;", 3.25},
+		{"1e3;", 1000},
+		{"2.5e-1;", 0.25},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input           string
@@ -386,6 +430,34 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a < b || c >= d && e",
+			"((a < b) || ((c >= d) && e))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"x = a || b",
+			"(x = (a || b))",
+		},
+		{
+			"a + b % c <= d",
+			"((a + (b % c)) <= d)",
+		},
+		{
+			"a = b = c + 1",
+			"(a = (b = (c + 1)))",
+		},
+		{
+			"a += b == c",
+			"(a += (b == c))",
+		},
+		{
+			"xs[i] *= 2 + 1",
+			"((xs[i]) *= (2 + 1))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -527,6 +599,114 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestFunctionDefaultAndRestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		rest     string
+	}{
+		{"fn(a, b = 2) {};", "fn(a, b = 2) ", ""},
+		{"fn(a = 1, b = a + 1) {};", "fn(a = 1, b = (a + 1)) ", ""},
+		{"fn(...xs) {};", "fn(...xs) ", "xs"},
+		{"fn(a, b = [1], ...rest) {};", "fn(a, b = [1], ...rest) ", "rest"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+		if function.String() != tt.expected {
+			t.Errorf("function.String() wrong. want=%q, got=%q", tt.expected, function.String())
+		}
+
+		if tt.rest == "" {
+			if function.Rest != nil {
+				t.Errorf("function.Rest is not nil. got=%s", function.Rest)
+			}
+		} else {
+			testIdentifier(t, function.Rest, tt.rest)
+		}
+
+		if function.Defaults != nil && len(function.Defaults) != len(function.Parameters) {
+			t.Errorf("function.Defaults has wrong length. want=%d, got=%d", len(function.Parameters), len(function.Defaults))
+		}
+	}
+}
+
+func TestDestructuringLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = xs;", "let [a, b] = xs;"},
+		{"let [a, ...rest] = xs;", "let [a, ...rest] = xs;"},
+		{"let [...all] = xs;", "let [...all] = xs;"},
+		{"let [] = xs;", "let [] = xs;"},
+		{`let {"name": n, "age": a} = person;`, "let {name: n, age: a} = person;"},
+		{`let {"pos": [x, y], "tags": {1: first}} = item;`, "let {pos: [x, y], tags: {1: first}} = item;"},
+		{"let [[a, b], {k: v}] = xs;", "let [[a, b], {k: v}] = xs;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.LetStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Name != nil {
+			t.Errorf("stmt.Name is not nil. got=%s", stmt.Name)
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("stmt.String() wrong. want=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestImportAndExport(t *testing.T) {
+	input := `let m = import "lib/math.mk";
+export let double = fn(x) { x * 2 };
+export let [a, b] = m["pair"];`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	}
+
+	let := program.Statements[0].(*ast.LetStatement)
+	if let.Exported {
+		t.Errorf("let m is exported")
+	}
+	imp, ok := let.Value.(*ast.ImportExpression)
+	if !ok {
+		t.Fatalf("let.Value is not *ast.ImportExpression. got=%T", let.Value)
+	}
+	if imp.Path.Value != "lib/math.mk" {
+		t.Errorf("imp.Path.Value wrong. want=%q, got=%q", "lib/math.mk", imp.Path.Value)
+	}
+
+	for _, statement := range program.Statements[1:] {
+		if !statement.(*ast.LetStatement).Exported {
+			t.Errorf("statement is not exported: %s", statement)
+		}
+	}
+
+	expected := `let m = import "lib/math.mk";export let double = fn(x) (x * 2);export let [a, b] = (m[pair]);`
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. want=%q, got=%q", expected, program.String())
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 
@@ -693,14 +873,22 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		"three": 3,
 	}
 
-	for key, value := range hash.Pairs {
-		literal, ok := key.(*ast.StringLiteral)
+	// the pairs keep the order they were written in
+	order := []string{"one", "two", "three"}
+
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
 		if !ok {
-			t.Errorf("key is not ast.StringLiteral. got=%T", key)
+			t.Errorf("key is not ast.StringLiteral. got=%T", pair.Key)
+			continue
+		}
+
+		if literal.String() != order[i] {
+			t.Errorf("pair %d has the wrong key. want=%q, got=%q", i, order[i], literal.String())
 		}
 
 		expectedValue := expected[literal.String()]
-		testIntegerLiteral(t, value, expectedValue)
+		testIntegerLiteral(t, pair.Value, expectedValue)
 	}
 }
 
@@ -755,10 +943,10 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		},
 	}
 
-	for key, value := range hash.Pairs {
-		literal, ok := key.(*ast.StringLiteral)
+	for _, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
 		if !ok {
-			t.Errorf("key is not ast.StringLiteral. got=%T", key)
+			t.Errorf("key is not ast.StringLiteral. got=%T", pair.Key)
 		}
 
 		testFunc, ok := tests[literal.String()]
@@ -767,6 +955,387 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 			continue
 		}
 
-		testFunc(value)
+		testFunc(pair.Value)
+	}
+}
+func TestFunctionLiteralWithName(t *testing.T) {
+	input := `let myFunction = fn() { };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
 	}
-}
\ No newline at end of file
+
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T",
+			program.Statements[0])
+	}
+
+	function, ok := stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.FunctionLiteral. got=%T",
+			stmt.Value)
+	}
+
+	if function.Name != "myFunction" {
+		t.Fatalf("function literal name wrong. want 'myFunction', got=%q\n",
+			function.Name)
+	}
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let = 5;", "1:5: expected next token to be IDENT, but received ="},
+		{"let x = 5;\nlet y 10;", "2:7: expected next token to be =, but received INT"},
+		{"if (x) {\n  1 }\n)", "3:1: No prefix parser function found for )"},
+		{"1e999", "1:1: Failed to parse \"1e999\" as a float"},
+		{"let s = \"oops;\nlet t = 1;", "1:9: unterminated string"},
+		{"puts(\"a\\qb\")", "1:8: invalid escape sequence \\q"},
+		{"let x = 1 ~ 2;", "1:11: unexpected character '~'"},
+		{"fn(a = 1, b) {}", "1:11: parameter b without a default follows one with a default"},
+		{"fn(...a, b) {}", "1:10: rest parameter ...a must be the last parameter"},
+		{"fn(...) {}", "1:7: expected next token to be IDENT, but received )"},
+		{"macro(...a) { a }", "1:1: macro parameters can't have default values or a rest parameter"},
+		{"fn(a, ..b) {}", "1:7: unexpected character '.'"},
+		{"fn(a, 1) {}", "1:7: expected a parameter name, but received INT"},
+		{"let x = 1;\nx + 1 = 2;", "2:3: cannot assign to (x + 1)"},
+		{"let [a, 1] = xs;", "1:9: expected a name, [ or { to bind to, but received INT"},
+		{"let [...a, b] = xs;", "1:10: rest element ...a must be the last element"},
+		{"import lib;", "1:8: expected next token to be STRING, but received IDENT"},
+		{"fn() { export let a = 1; }", "1:8: export is only allowed at the top level"},
+		{"export a = 1;", "1:8: expected next token to be LET, but received IDENT"},
+		{"let {\"a\" b} = h;", "1:10: expected next token to be :, but received IDENT"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tt.input)
+			continue
+		}
+
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("wrong first error. expected=%q, got=%q", tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
+func TestParseErrorFields(t *testing.T) {
+	l := lexer.New("let x 5;")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got=%d (%v)", len(errors), errors)
+	}
+
+	err := errors[0]
+	if err.Pos.Line != 1 || err.Pos.Column != 7 {
+		t.Errorf("err.Pos wrong. got=%s", err.Pos)
+	}
+	if len(err.Expected) != 1 || err.Expected[0] != token.ASSIGN {
+		t.Errorf("err.Expected wrong. got=%v", err.Expected)
+	}
+	if err.Found.Type != token.INT || err.Found.Literal != "5" {
+		t.Errorf("err.Found wrong. got=%+v", err.Found)
+	}
+	if err.Message != "expected next token to be =, but received INT" {
+		t.Errorf("err.Message wrong. got=%q", err.Message)
+	}
+}
+
+func TestParserErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements int
+	}{
+		{
+			// one bad token only reports one error
+			"let = 5;",
+			[]string{"1:5: expected next token to be IDENT, but received ="},
+			0,
+		},
+		{
+			// independent errors in separate statements are all reported
+			"let = 1;\nlet x 2;\nlet y = 3;\nadd(1, 2;",
+			[]string{
+				"1:5: expected next token to be IDENT, but received =",
+				"2:7: expected next token to be =, but received INT",
+				"4:9: expected next token to be ), but received ;",
+			},
+			1,
+		},
+		{
+			// errors inside a block don't swallow the closing brace
+			"let f = fn() { let = 1; return 2; };\nlet g = 3;\n)",
+			[]string{
+				"1:20: expected next token to be IDENT, but received =",
+				"3:1: No prefix parser function found for )",
+			},
+			1,
+		},
+		{
+			"if (x { 1 }\nlet y = 2;",
+			[]string{"1:7: expected next token to be ), but received {"},
+			1,
+		},
+		{
+			"break;\nwhile (true) { let f = fn() { continue; }; break; }",
+			[]string{
+				"1:1: break outside of a loop",
+				"2:31: continue outside of a loop",
+			},
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("wrong number of errors for %q. expected=%d, got=%d (%v)",
+				tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+
+		for i, expected := range tt.expectedErrors {
+			if errors[i].Error() != expected {
+				t.Errorf("wrong error %d. expected=%q, got=%q", i, expected, errors[i].Error())
+			}
+		}
+
+		if len(program.Statements) != tt.expectedStatements {
+			t.Errorf("wrong number of statements for %q. expected=%d, got=%d",
+				tt.input, tt.expectedStatements, len(program.Statements))
+		}
+	}
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MacroLiteral. got=%T",
+			stmt.Expression)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("macro literal parameters wrong. want 2, got=%d\n",
+			len(macro.Parameters))
+	}
+
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	testLiteralExpression(t, macro.Parameters[1], "y")
+
+	if len(macro.Body.Statements) != 1 {
+		t.Fatalf("macro.Body.Statements has not 1 statements. got=%d\n",
+			len(macro.Body.Statements))
+	}
+
+	bodyStmt, ok := macro.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("macro body stmt is not ast.ExpressionStatement. got=%T",
+			macro.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < y) { x; break; continue; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.WhileStatement. got=%T", program.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 3 {
+		t.Fatalf("body does not contain 3 statements. got=%d", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[1].(*ast.BreakStatement); !ok {
+		t.Errorf("Statements[1] is not ast.BreakStatement. got=%T", stmt.Body.Statements[1])
+	}
+
+	if _, ok := stmt.Body.Statements[2].(*ast.ContinueStatement); !ok {
+		t.Errorf("Statements[2] is not ast.ContinueStatement. got=%T", stmt.Body.Statements[2])
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for (let i = 0; i < 10; i) { i }", "for (let i = 0; (i < 10); i) i"},
+		{"for (;;) { break; }", "for (; ; ) break;"},
+		{"for (i; ; f(i)) { }", "for (i; ; f(i)) "},
+		{"for (x in xs) { x }", "for (x in xs) x"},
+		{"for (k, v in {}) { v }", "for (k, v in {}) v"},
+		{"for (x in [1, 2]) { x };\nx", "for (x in [1, 2]) xx"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestForInStatement(t *testing.T) {
+	input := `for (i, x in [1]) { x }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ForInStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.ForInStatement. got=%T", program.Statements[0])
+	}
+
+	if !testIdentifier(t, stmt.Key, "i") || !testIdentifier(t, stmt.Value, "x") {
+		return
+	}
+
+	if _, ok := stmt.Iterable.(*ast.ArrayLiteral); !ok {
+		t.Errorf("stmt.Iterable is not ast.ArrayLiteral. got=%T", stmt.Iterable)
+	}
+}
+
+func TestAssignExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator string
+		target   string
+		value    string
+	}{
+		{"x = 5;", "=", "x", "5"},
+		{"x -= y;", "-=", "x", "y"},
+		{`h["k"] /= 2;`, "/=", "(h[k])", "2"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.AssignExpression. got=%T", stmt.Expression)
+		}
+
+		if exp.Operator != tt.operator {
+			t.Errorf("exp.Operator is not %q. got=%q", tt.operator, exp.Operator)
+		}
+		if exp.Target.String() != tt.target {
+			t.Errorf("exp.Target is not %s. got=%s", tt.target, exp.Target.String())
+		}
+		if exp.Value.String() != tt.value {
+			t.Errorf("exp.Value is not %s. got=%s", tt.value, exp.Value.String())
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// adds two numbers
+let add = fn(a, b) {
+  a + b // no return needed
+};
+/* the end */`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []struct {
+		text string
+		pos  string
+	}{
+		{"// adds two numbers", "1:1"},
+		{"// no return needed", "3:9"},
+		{"/* the end */", "5:1"},
+	}
+
+	if len(program.Comments) != len(expected) {
+		t.Fatalf("program.Comments has wrong length. want=%d, got=%d", len(expected), len(program.Comments))
+	}
+
+	for i, tt := range expected {
+		comment := program.Comments[i]
+		if comment.Text != tt.text || comment.Pos.String() != tt.pos {
+			t.Errorf("program.Comments[%d] wrong. want=%s %q, got=%s %q", i, tt.pos, tt.text, comment.Pos, comment.Text)
+		}
+	}
+
+	let := program.Statements[0].(*ast.LetStatement)
+	if len(let.Token.Leading) != 1 || let.Token.Leading[0].Text != "// adds two numbers" {
+		t.Errorf("let statement's leading comments wrong. got=%v", let.Token.Leading)
+	}
+
+	if program.String() != "let add = fn(a, b) (a + b);" {
+		t.Errorf("comments changed program.String(). got=%q", program.String())
+	}
+
+	if fn := let.Value.(*ast.FunctionLiteral); fn.Name != "add" {
+		t.Errorf("function literal's name wrong. want=%q, got=%q", "add", fn.Name)
+	}
+}
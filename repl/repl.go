@@ -4,13 +4,22 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"monkey/ast"
+	"monkey/compiler"
 	"monkey/evaluator"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"monkey/vm"
 )
 
 const PROMPT = ">> "
+
+// The engines a program can be run with, the tree-walking evaluator is the default.
+const (
+  ENGINE_EVAL = "eval"
+  ENGINE_VM   = "vm"
+)
 const MONKEY_FACE = `            
             __,__
    .--.  .-"     "-.  .--.
@@ -25,9 +34,17 @@ const MONKEY_FACE = `
            '-----'
 `
 
-func Start(in io.Reader, out io.Writer) {
+// Start reads and runs lines from in until it ends, searchPaths are where the evaluator looks for imported files.
+func Start(in io.Reader, out io.Writer, engine string, searchPaths []string) {
+  if engine == ENGINE_VM {
+    startVM(in, out)
+    return
+  }
+
   scanner := bufio.NewScanner(in)
   env := object.NewEnvironment()
+  env.SetModules(object.NewModules(searchPaths...))
+  macroEnv := object.NewEnvironment()
 
   for {
     fmt.Fprintf(out, PROMPT)
@@ -47,8 +64,19 @@ func Start(in io.Reader, out io.Writer) {
       continue
     }
 
+    program, errObj := expandMacros(program, macroEnv)
+    if errObj != nil {
+      io.WriteString(out, errObj.Inspect())
+      io.WriteString(out, "\n")
+      continue
+    }
+
     evaluated := evaluator.Eval(program, env)
 
+    if errObj, ok := evaluated.(*object.Error); ok {
+      io.WriteString(out, errObj.Traceback())
+    }
+
     if evaluated != nil {
       io.WriteString(out, evaluated.Inspect())
       io.WriteString(out, "\n")
@@ -56,11 +84,96 @@ func Start(in io.Reader, out io.Writer) {
   }
 }
 
-func printParserErrors(out io.Writer, errors []string) {
+// startVM compiles and runs each line on its own, the symbol table, constants and globals
+// are carried over so later lines can use what earlier ones defined.
+func startVM(in io.Reader, out io.Writer) {
+  scanner := bufio.NewScanner(in)
+
+  macroEnv := object.NewEnvironment()
+  constants := []object.Object{}
+  globals := make([]object.Object, vm.GlobalsSize)
+  symbolTable := compiler.NewSymbolTable()
+  for i, v := range object.Builtins {
+    symbolTable.DefineBuiltin(i, v.Name)
+  }
+
+  for {
+    fmt.Fprintf(out, PROMPT)
+    scanned := scanner.Scan()
+    if !scanned {
+      return
+    }
+
+    line := scanner.Text()
+    lexer := lexer.New(line)
+    parser := parser.New(lexer)
+
+    program := parser.ParseProgram()
+
+    if len(parser.Errors()) != 0 {
+      printParserErrors(out, parser.Errors())
+      continue
+    }
+
+    program, errObj := expandMacros(program, macroEnv)
+    if errObj != nil {
+      io.WriteString(out, errObj.Inspect())
+      io.WriteString(out, "\n")
+      continue
+    }
+
+    if len(program.Statements) == 0 {
+      continue
+    }
+
+    comp := compiler.NewWithState(symbolTable, constants)
+    err := comp.Compile(program)
+    if err != nil {
+      fmt.Fprintf(out, "Woops! Compilation failed:\n %s\n", err)
+      continue
+    }
+
+    code := comp.Bytecode()
+    constants = code.Constants
+
+    machine := vm.NewWithGlobalsStore(code, globals)
+    err = machine.Run()
+    if err != nil {
+      fmt.Fprintf(out, "Woops! Executing bytecode failed:\n %s\n", err)
+      continue
+    }
+
+    // A line ending in a let statement leaves nothing to show, the same as the evaluator
+    if _, ok := program.Statements[len(program.Statements)-1].(*ast.LetStatement); ok {
+      continue
+    }
+
+    lastPopped := machine.LastPoppedStackElem()
+    if lastPopped != nil {
+      io.WriteString(out, lastPopped.Inspect())
+      io.WriteString(out, "\n")
+    }
+  }
+}
+
+// expandMacros defines the line's macros in macroEnv, which lives as long as the session, and expands
+// every macro call in the line.
+func expandMacros(program *ast.Program, macroEnv *object.Environment) (*ast.Program, *object.Error) {
+  evaluator.DefineMacros(program, macroEnv)
+
+  expanded, errObj := evaluator.ExpandMacros(program, macroEnv)
+  if errObj != nil {
+    return nil, errObj
+  }
+
+  return expanded.(*ast.Program), nil
+}
+
+func printParserErrors(out io.Writer, errors []*parser.ParseError) {
   io.WriteString(out, MONKEY_FACE)
   io.WriteString(out, "Woops! We ran into some monkey business here!\n")
   io.WriteString(out, " errors during parsing:\n")
-  for _, msg := range errors {
-    io.WriteString(out, "\t" + msg + "\n")
+  for _, err := range errors {
+    io.WriteString(out, "\t" + err.Error() + "\n")
   }
 }
@@ -1,10 +1,51 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 type Token struct {
   Type    TokenType
   Literal string
+  Pos     Position  // where the first character of the token is in the source
+  Leading []Comment // the comments between the previous token and this one, nil if there are none
+}
+
+// Comment is a // line comment or a /* */ block comment. Comments don't affect the program, the lexer
+// keeps them as trivia on the token that follows so tools like formatters can put them back. A comment
+// at the end of a line belongs to the token on the next line, its Pos tells the two cases apart.
+type Comment struct {
+  Pos  Position
+  Text string // the comment including its delimiters, without the newline ending a line comment
+}
+
+// Position is a location in a source file, lines and columns start at 1 and columns are counted in bytes.
+type Position struct {
+  Filename string // empty when the source didn't come from a file, e.g. the REPL
+  Offset   int    // byte offset from the start of the source, starting at 0
+  Line     int
+  Column   int
+}
+
+// IsValid reports whether the position was set by the lexer, the zero Position is not valid.
+func (pos Position) IsValid() bool {
+  return pos.Line > 0
+}
+
+// String formats the position as file:line:column, which most editors know how to jump to.
+func (pos Position) String() string {
+  if !pos.IsValid() {
+    if pos.Filename != "" {
+      return pos.Filename
+    }
+    return "-"
+  }
+
+  if pos.Filename != "" {
+    return fmt.Sprintf("%s:%d:%d", pos.Filename, pos.Line, pos.Column)
+  }
+
+  return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
 }
 
 const (
@@ -14,6 +55,7 @@ const (
   // Variable identifiers and literal values
   IDENT     = "IDENT" // variable identifiers, foo, bar, user, etc.
   INT       = "INT"   // 123, 3555
+  FLOAT     = "FLOAT" // 1.5, 2e10, 6.02e23
   STRING    = "STRING"
   
   // Operators
@@ -23,8 +65,19 @@ const (
   BANG      = "!"
   ASTERISK  = "*"
   SLASH     = "/"
+  PERCENT   = "%"
   LT        = "<"
   GT        = ">"
+  LT_EQ     = "<="
+  GT_EQ     = ">="
+  AND       = "&&"
+  OR        = "||"
+  ELLIPSIS  = "..."
+
+  PLUS_ASSIGN     = "+="
+  MINUS_ASSIGN    = "-="
+  ASTERISK_ASSIGN = "*="
+  SLASH_ASSIGN    = "/="
 
   // Delimitters
   COMMA     = ","
@@ -47,16 +100,32 @@ const (
   IF        = "IF"
   ELSE      = "ELSE"
   RETURN    = "RETURN"
+  MACRO     = "MACRO"
+  WHILE     = "WHILE"
+  FOR       = "FOR"
+  IN        = "IN"
+  BREAK     = "BREAK"
+  CONTINUE  = "CONTINUE"
+  IMPORT    = "IMPORT"
+  EXPORT    = "EXPORT"
 )
 
 var keywords = map[string]TokenType {
-  "fn":     FUNCTION,
-  "let":    LET,
-  "true":   TRUE,
-  "false":  FALSE,
-  "if":     IF,
-  "else":   ELSE,
-  "return": RETURN,
+  "fn":       FUNCTION,
+  "let":      LET,
+  "true":     TRUE,
+  "false":    FALSE,
+  "if":       IF,
+  "else":     ELSE,
+  "return":   RETURN,
+  "macro":    MACRO,
+  "while":    WHILE,
+  "for":      FOR,
+  "in":       IN,
+  "break":    BREAK,
+  "continue": CONTINUE,
+  "import":   IMPORT,
+  "export":   EXPORT,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -0,0 +1,21 @@
+package vm
+
+import (
+	"monkey/code"
+	"monkey/object"
+)
+
+// Frame is the call-relevant state of one function invocation.
+type Frame struct {
+	cl          *object.Closure
+	ip          int // instruction pointer within this frame's function
+	basePointer int // stack pointer before the call, locals live just above it
+}
+
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer}
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
@@ -0,0 +1,490 @@
+package vm
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/compiler"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+// The cases below mirror evaluator_test.go, both engines have to agree on every one of them.
+
+type vmTestCase struct {
+	input    string
+	expected interface{}
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"5", 5},
+		{"10", 10},
+		{"-5", -5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"2 * (5 + 10)", 30},
+		{"3 * 3 * 3 + 10", 37},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 < 1", false},
+		{"1 > 1", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1 == 2", false},
+		{"1 != 2", true},
+		{"true == true", true},
+		{"false == false", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"false != true", true},
+		{"(1 < 2) == true", true},
+		{"(1 < 2) == false", false},
+		{"(1 > 2) == true", false},
+		{"(1 > 2) == false", true},
+		{"!true", false},
+		{"!false", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!!false", false},
+		{"!!5", true},
+		{"!(if (false) { 5; })", true},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []vmTestCase{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", Null},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", Null},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if ((if (false) { 10 })) { 10 } else { 20 }", 20},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []vmTestCase{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"if (10 > 1) { if (10 > 1) { return 10; } return 1; }", 10},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 + true;", "1:3: type mismatch: INTEGER + BOOLEAN"},
+		{"5 + true; 5;", "1:3: type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "1:1: unknown operator: -BOOLEAN"},
+		{"true + false;", "1:6: unknown operator: BOOLEAN + BOOLEAN"},
+		{"5; true + false; 5", "1:9: unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) { true + false; }", "1:20: unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) { if (10 > 1) { return true + false; } return 1; }", "1:41: unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "1:1: identifier not found: foobar"},
+		{`"Hello" - "World"`, "1:9: unknown operator: STRING - STRING"},
+		{`{"name": "Monkey"}[fn(x) { x }];`, "1:19: unusable as hash key: FUNCTION"},
+		{"fn(a) { a }()", "1:12: wrong number of arguments: want=1, got=0"},
+		{"1(2)", "1:2: not a function: INTEGER"},
+		{`int("99999999999999999999") + 1`, "1:29: integers beyond 64 bits are not supported by the vm"},
+		{"map([1], fn(x) { x })", "1:4: builtins that call functions are not supported by the vm"},
+		{"1 / 0", "1:3: division by zero"},
+		{"len(1)", "1:4: argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "1:4: wrong number of arguments. got=2, expected=1"},
+		{"keys(1)", "1:5: argument to `keys` must be HASH, got INTEGER"},
+		{`let x = len(1); puts("after");`, "1:12: argument to `len` not supported, got INTEGER"},
+		{"let x = 0; 10 / x", "1:15: division by zero"},
+		{"(-9223372036854775807 - 1) / -1", "1:28: integers beyond 64 bits are not supported by the vm"},
+	}
+
+	for _, tt := range tests {
+		err := runProgram(tt.input)
+		if err == nil {
+			t.Errorf("expected an error for %q, got none", tt.input)
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []vmTestCase{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []vmTestCase{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let noReturn = fn() { }; noReturn();", Null},
+		{"let globalSeed = 50; let minusOne = fn() { let num = 1; globalSeed - num; }; minusOne();", 49},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestClosures(t *testing.T) {
+	tests := []vmTestCase{
+		{
+			`
+			let newAdder = fn(x) {
+				fn(y) { x + y };
+			};
+
+			let addTwo = newAdder(2);
+			addTwo(2);
+			`,
+			4,
+		},
+		{
+			`
+			let newAdderOuter = fn(a, b) {
+				let c = a + b;
+				fn(d) {
+					let e = d + c;
+					fn(f) { e + f; };
+				};
+			};
+			let newAdderInner = newAdderOuter(1, 2);
+			let adder = newAdderInner(3);
+			adder(8);
+			`,
+			14,
+		},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestRecursiveFunctions(t *testing.T) {
+	tests := []vmTestCase{
+		{
+			`
+			let fibonacci = fn(x) {
+				if (x == 0) {
+					return 0;
+				} else {
+					if (x == 1) {
+						return 1;
+					} else {
+						fibonacci(x - 1) + fibonacci(x - 2);
+					}
+				}
+			};
+			fibonacci(15);
+			`,
+			610,
+		},
+		{
+			`
+			let wrapper = fn() {
+				let countDown = fn(x) {
+					if (x == 0) { return 0; } else { countDown(x - 1); }
+				};
+				countDown(1);
+			};
+			wrapper();
+			`,
+			0,
+		},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestStringExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{`"Hello World!"`, "Hello World!"},
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []vmTestCase{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("hello world")`, 11},
+		{`len([1, 2, 3])`, 3},
+		{`first([1, 2, 3])`, 1},
+		{`first([])`, Null},
+		{`last([1, 2, 3])`, 3},
+		{`rest([1, 2, 3])`, []int{2, 3}},
+		{`push([], 1)`, []int{1}},
+		{`puts("hello", "world!")`, Null},
+		{`keys({"b": 2, "a": 1})[0]`, "b"},
+		{`size(delete({"a": 1, "b": 2}, "a"))`, 1},
+		{`has(merge({"a": 1}, {"b": 2}), "b")`, true},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestArrayLiterals(t *testing.T) {
+	tests := []vmTestCase{
+		{"[]", []int{}},
+		{"[1, 2 * 2, 3 + 3]", []int{1, 4, 6}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestIndexExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
+		{"[1, 2, 3][3]", Null},
+		{"[1, 2, 3][-1]", Null},
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, Null},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, Null},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestHashLiterals(t *testing.T) {
+	tests := []vmTestCase{
+		{
+			`let two = "two";
+			{
+				"one": 10 - 9,
+				two: 1 + 1,
+				"thr" + "ee": 6 / 2,
+				4: 4,
+				true: 5,
+				false: 6
+			}`,
+			map[object.HashKey]int64{
+				(&object.String{Value: "one"}).HashKey():   1,
+				(&object.String{Value: "two"}).HashKey():   2,
+				(&object.String{Value: "three"}).HashKey(): 3,
+				(&object.Integer{Value: 4}).HashKey():      4,
+				True.HashKey():                             5,
+				False.HashKey():                            6,
+			},
+		},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestRecursionDepth(t *testing.T) {
+	err := runProgram("let f = fn(x) { f(x + 1) }; f(0);")
+	if err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+
+	if err.Error() != "1:23: stack overflow" {
+		t.Errorf("wrong error message. got=%q", err.Error())
+	}
+}
+
+func runProgram(input string) error {
+	program := parse(input)
+
+	comp := compiler.New()
+	err := comp.Compile(program)
+	if err != nil {
+		return err
+	}
+
+	vm := New(comp.Bytecode())
+	return vm.Run()
+}
+
+func runVmTests(t *testing.T, tests []vmTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		program := parse(tt.input)
+
+		comp := compiler.New()
+		err := comp.Compile(program)
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		vm := New(comp.Bytecode())
+		err = vm.Run()
+		if err != nil {
+			t.Fatalf("vm error: %s", err)
+		}
+
+		stackElem := vm.LastPoppedStackElem()
+
+		testExpectedObject(t, tt.expected, stackElem)
+	}
+}
+
+func parse(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
+
+func testExpectedObject(t *testing.T, expected interface{}, actual object.Object) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		err := testIntegerObject(int64(expected), actual)
+		if err != nil {
+			t.Errorf("testIntegerObject failed: %s", err)
+		}
+
+	case bool:
+		err := testBooleanObject(expected, actual)
+		if err != nil {
+			t.Errorf("testBooleanObject failed: %s", err)
+		}
+
+	case string:
+		str, ok := actual.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", actual, actual)
+			return
+		}
+		if str.Value != expected {
+			t.Errorf("object has wrong value. got=%q, want=%q", str.Value, expected)
+		}
+
+	case *object.Null:
+		if actual != Null {
+			t.Errorf("object is not Null: %T (%+v)", actual, actual)
+		}
+
+	case *object.Error:
+		errObj, ok := actual.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error: %T (%+v)", actual, actual)
+			return
+		}
+		if errObj.Message != expected.Message {
+			t.Errorf("wrong error message. expected=%q, got=%q", expected.Message, errObj.Message)
+		}
+
+	case []int:
+		array, ok := actual.(*object.Array)
+		if !ok {
+			t.Errorf("object not Array: %T (%+v)", actual, actual)
+			return
+		}
+
+		if len(array.Elements) != len(expected) {
+			t.Errorf("wrong num of elements. want=%d, got=%d", len(expected), len(array.Elements))
+			return
+		}
+
+		for i, expectedElem := range expected {
+			err := testIntegerObject(int64(expectedElem), array.Elements[i])
+			if err != nil {
+				t.Errorf("testIntegerObject failed: %s", err)
+			}
+		}
+
+	case map[object.HashKey]int64:
+		hash, ok := actual.(*object.Hash)
+		if !ok {
+			t.Errorf("object is not Hash. got=%T (%+v)", actual, actual)
+			return
+		}
+
+		if len(hash.Pairs) != len(expected) {
+			t.Errorf("hash has wrong number of Pairs. want=%d, got=%d", len(expected), len(hash.Pairs))
+			return
+		}
+
+		for expectedKey, expectedValue := range expected {
+			pair, ok := hash.Pairs[expectedKey]
+			if !ok {
+				t.Errorf("no pair for given key in Pairs")
+			}
+
+			err := testIntegerObject(expectedValue, pair.Value)
+			if err != nil {
+				t.Errorf("testIntegerObject failed: %s", err)
+			}
+		}
+	}
+}
+
+func testIntegerObject(expected int64, actual object.Object) error {
+	result, ok := actual.(*object.Integer)
+	if !ok {
+		return fmt.Errorf("object is not Integer. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value != expected {
+		return fmt.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+	}
+
+	return nil
+}
+
+func testBooleanObject(expected bool, actual object.Object) error {
+	result, ok := actual.(*object.Boolean)
+	if !ok {
+		return fmt.Errorf("object is not Boolean. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value != expected {
+		return fmt.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+	}
+
+	return nil
+}